@@ -0,0 +1,221 @@
+// analyser.go
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	fftSize          = 1024
+	analyserMinFreq  = 40.0
+	analyserMaxFreq  = 16000.0
+	analyserFalloff  = 0.04 // bar fall per frame
+	analyserPeakHold = 30   // frames before a peak starts to drop
+	analyserSegment  = 3    // height of one LED segment in pixels
+)
+
+// AnalyserConfig configures the spectrum analyser overlay
+type AnalyserConfig struct {
+	Enabled   bool   `json:"enabled"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Bands     int    `json:"bands"`
+	ColorLow  string `json:"colorLow"`
+	ColorHigh string `json:"colorHigh"`
+	ColorPeak string `json:"colorPeak"`
+}
+
+// analyser computes per-band FFT magnitudes from the music stream
+type analyser struct {
+	mu     sync.Mutex
+	ring   [fftSize]float64
+	pos    int
+	buf    []complex128
+	bands  []float64
+	peaks  []float64
+	holds  []int
+	edges  []int
+	window []float64
+
+	low, high, peak color.RGBA
+}
+
+// newAnalyser creates an analyser with log-spaced bands
+func newAnalyser(cfg *AnalyserConfig, sampleRate int) *analyser {
+	a := &analyser{
+		buf:    make([]complex128, fftSize),
+		bands:  make([]float64, cfg.Bands),
+		peaks:  make([]float64, cfg.Bands),
+		holds:  make([]int, cfg.Bands),
+		edges:  make([]int, cfg.Bands+1),
+		window: make([]float64, fftSize),
+		low:    parseHexColor(cfg.ColorLow, color.RGBA{0, 255, 0, 255}),
+		high:   parseHexColor(cfg.ColorHigh, color.RGBA{255, 0, 0, 255}),
+		peak:   parseHexColor(cfg.ColorPeak, color.RGBA{255, 255, 255, 255}),
+	}
+
+	// Hann window
+	for i := range a.window {
+		a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(fftSize-1))
+	}
+
+	// Band edges in FFT bins, spaced logarithmically
+	binHz := float64(sampleRate) / fftSize
+	ratio := analyserMaxFreq / analyserMinFreq
+	for i := range a.edges {
+		freq := analyserMinFreq * math.Pow(ratio, float64(i)/float64(cfg.Bands))
+		bin := int(math.Round(freq / binHz))
+		if i > 0 && bin <= a.edges[i-1] {
+			bin = a.edges[i-1] + 1
+		}
+		a.edges[i] = min(bin, fftSize/2)
+	}
+
+	return a
+}
+
+// writeSamples stores samples in the ring buffer (called from the audio goroutine)
+func (a *analyser) writeSamples(samples []float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, s := range samples {
+		a.ring[a.pos] = s
+		a.pos = (a.pos + 1) % fftSize
+	}
+}
+
+// update runs the FFT on the latest samples and animates the bars
+func (a *analyser) update() {
+	a.mu.Lock()
+	for i := 0; i < fftSize; i++ {
+		a.buf[i] = complex(a.ring[(a.pos+i)%fftSize]*a.window[i], 0)
+	}
+	a.mu.Unlock()
+
+	fft(a.buf)
+
+	for b := range a.bands {
+		mag := 0.0
+		for k := a.edges[b]; k < max(a.edges[b+1], a.edges[b]+1) && k < fftSize/2; k++ {
+			mag = max(mag, cmplx.Abs(a.buf[k]))
+		}
+
+		// Map to 0..1 over a 60dB range
+		level := 0.0
+		if mag > 0 {
+			level = (20*math.Log10(mag/(fftSize/4)) + 60) / 60
+		}
+		level = math.Max(0, math.Min(1, level))
+
+		if level > a.bands[b] {
+			a.bands[b] = level
+		} else {
+			a.bands[b] = math.Max(level, a.bands[b]-analyserFalloff)
+		}
+
+		if a.bands[b] >= a.peaks[b] {
+			a.peaks[b] = a.bands[b]
+			a.holds[b] = analyserPeakHold
+		} else if a.holds[b] > 0 {
+			a.holds[b]--
+		} else {
+			a.peaks[b] = math.Max(0, a.peaks[b]-analyserFalloff/2)
+		}
+	}
+}
+
+// draw renders the bars as LED segments, coordinates are in native pixels
+func (a *analyser) draw(screen *ebiten.Image, cfg *AnalyserConfig, scale float64) {
+	n := len(a.bands)
+	barW := float64(cfg.Width) / float64(n)
+	segments := max(1, cfg.Height/analyserSegment)
+
+	for b := 0; b < n; b++ {
+		x := float64(cfg.X) + float64(b)*barW
+		lit := int(math.Round(a.bands[b] * float64(segments)))
+
+		for s := 0; s < lit; s++ {
+			t := float64(s) / float64(max(1, segments-1))
+			y := float64(cfg.Y+cfg.Height) - float64((s+1)*analyserSegment)
+			vector.DrawFilledRect(screen,
+				float32(x*scale), float32(y*scale),
+				float32((barW-1)*scale), float32((analyserSegment-1)*scale),
+				lerpColor(a.low, a.high, t), false)
+		}
+
+		if a.peaks[b] > 0 {
+			s := min(segments-1, int(math.Round(a.peaks[b]*float64(segments))))
+			y := float64(cfg.Y+cfg.Height) - float64((s+1)*analyserSegment)
+			vector.DrawFilledRect(screen,
+				float32(x*scale), float32(y*scale),
+				float32((barW-1)*scale), float32((analyserSegment-1)*scale),
+				a.peak, false)
+		}
+	}
+}
+
+// fft performs an in-place iterative radix-2 FFT (len(x) must be a power of two)
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * w
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
+
+// parseHexColor parses "#RRGGBB" or "#RRGGBBAA", returning def on error
+func parseHexColor(s string, def color.RGBA) color.RGBA {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 8 {
+		return def
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return def
+	}
+	if len(s) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.RGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+// lerpColor interpolates between two colours
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
@@ -0,0 +1,321 @@
+// avi.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+)
+
+const (
+	aviJPEGQuality = 90
+	aviMaxBytes    = 2000 << 20 // stay clear of the 2 GB limit of AVI 1.0 readers
+	aviKeyFrame    = 0x10
+)
+
+// aviIndexEntry is one idx1 record, offsets are relative to the movi list
+type aviIndexEntry struct {
+	id     string
+	offset uint32
+	size   uint32
+}
+
+// aviWriter writes an AVI with one video stream, uncompressed 24-bit RGB or
+// MJPEG, and one 16-bit stereo PCM stream. Counts are patched on Close
+type aviWriter struct {
+	f          *os.File
+	codec      string // "raw" or "mjpeg"
+	width      int
+	height     int
+	fps        int
+	sampleRate int
+
+	frames     uint32
+	audioBytes uint32
+	moviBytes  uint32 // chunk data after the movi fourcc
+	maxChunk   uint32
+	index      []aviIndexEntry
+
+	buf  []byte
+	jpeg bytes.Buffer
+}
+
+// newAVIWriter creates the file and writes a placeholder header
+func newAVIWriter(path, codec string, width, height, fps, sampleRate int) (*aviWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &aviWriter{f: f, codec: codec, width: width, height: height, fps: fps, sampleRate: sampleRate}
+	if _, err := f.Write(w.header()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// aviMainHeader is the avih chunk
+type aviMainHeader struct {
+	MicroSecPerFrame    uint32
+	MaxBytesPerSec      uint32
+	PaddingGranularity  uint32
+	Flags               uint32
+	TotalFrames         uint32
+	InitialFrames       uint32
+	Streams             uint32
+	SuggestedBufferSize uint32
+	Width               uint32
+	Height              uint32
+	Reserved            [4]uint32
+}
+
+// aviStreamHeader is the strh chunk
+type aviStreamHeader struct {
+	Type                [4]byte
+	Handler             [4]byte
+	Flags               uint32
+	Priority            uint16
+	Language            uint16
+	InitialFrames       uint32
+	Scale               uint32
+	Rate                uint32
+	Start               uint32
+	Length              uint32
+	SuggestedBufferSize uint32
+	Quality             uint32
+	SampleSize          uint32
+	Frame               [4]int16
+}
+
+// bitmapInfoHeader is the strf chunk of the video stream
+type bitmapInfoHeader struct {
+	Size          uint32
+	Width         int32
+	Height        int32 // positive, rows are stored bottom-up
+	Planes        uint16
+	BitCount      uint16
+	Compression   [4]byte
+	SizeImage     uint32
+	XPelsPerMeter int32
+	YPelsPerMeter int32
+	ClrUsed       uint32
+	ClrImportant  uint32
+}
+
+// waveFormatEx is the strf chunk of the audio stream
+type waveFormatEx struct {
+	FormatTag      uint16
+	Channels       uint16
+	SamplesPerSec  uint32
+	AvgBytesPerSec uint32
+	BlockAlign     uint16
+	BitsPerSample  uint16
+	Size           uint16
+}
+
+// riffChunk encodes a chunk holding the little-endian encoding of v
+func riffChunk(id string, v any) []byte {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, v)
+
+	out := make([]byte, 8, 8+data.Len())
+	copy(out, id)
+	binary.LittleEndian.PutUint32(out[4:], uint32(data.Len()))
+	return append(out, data.Bytes()...)
+}
+
+// riffList encodes a LIST of the given kind around already encoded chunks
+func riffList(kind string, chunks ...[]byte) []byte {
+	out := make([]byte, 12)
+	copy(out, "LIST")
+	copy(out[8:], kind)
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
+
+// header builds the RIFF and hdrl headers and opens the movi list, its size
+// doesn't depend on the counts so it can be rewritten in place on Close
+func (w *aviWriter) header() []byte {
+	const blockAlign = 4
+	frameBytes := uint32((w.width*3+3)&^3) * uint32(w.height)
+	audioRate := uint32(w.sampleRate * blockAlign)
+	frame := [4]int16{0, 0, int16(w.width), int16(w.height)}
+
+	var compression, handler [4]byte
+	copy(handler[:], "DIB ")
+	if w.codec == "mjpeg" {
+		copy(compression[:], "MJPG")
+		copy(handler[:], "MJPG")
+	}
+
+	hdrl := riffList("hdrl",
+		riffChunk("avih", aviMainHeader{
+			MicroSecPerFrame:    uint32(1000000 / w.fps),
+			MaxBytesPerSec:      frameBytes*uint32(w.fps) + audioRate,
+			Flags:               0x10 | 0x100, // AVIF_HASINDEX | AVIF_ISINTERLEAVED
+			TotalFrames:         w.frames,
+			Streams:             2,
+			SuggestedBufferSize: w.maxChunk,
+			Width:               uint32(w.width),
+			Height:              uint32(w.height),
+		}),
+		riffList("strl",
+			riffChunk("strh", aviStreamHeader{
+				Type:                [4]byte{'v', 'i', 'd', 's'},
+				Handler:             handler,
+				Scale:               1,
+				Rate:                uint32(w.fps),
+				Length:              w.frames,
+				SuggestedBufferSize: w.maxChunk,
+				Quality:             0xffffffff,
+				Frame:               frame,
+			}),
+			riffChunk("strf", bitmapInfoHeader{
+				Size:        40,
+				Width:       int32(w.width),
+				Height:      int32(w.height),
+				Planes:      1,
+				BitCount:    24,
+				Compression: compression,
+				SizeImage:   frameBytes,
+			}),
+		),
+		riffList("strl",
+			riffChunk("strh", aviStreamHeader{
+				Type:                [4]byte{'a', 'u', 'd', 's'},
+				Scale:               blockAlign,
+				Rate:                audioRate,
+				Length:              w.audioBytes / blockAlign,
+				SuggestedBufferSize: audioRate,
+				Quality:             0xffffffff,
+				SampleSize:          blockAlign,
+			}),
+			riffChunk("strf", waveFormatEx{
+				FormatTag:      1, // PCM
+				Channels:       2,
+				SamplesPerSec:  uint32(w.sampleRate),
+				AvgBytesPerSec: audioRate,
+				BlockAlign:     blockAlign,
+				BitsPerSample:  16,
+			}),
+		),
+	)
+
+	h := make([]byte, 12, 12+len(hdrl)+12)
+	copy(h, "RIFF")
+	copy(h[8:], "AVI ")
+	h = append(h, hdrl...)
+	h = append(h, "LIST\x00\x00\x00\x00movi"...)
+
+	fileSize := uint32(len(h)) + w.moviBytes + 8 + uint32(len(w.index)*16)
+	binary.LittleEndian.PutUint32(h[4:], fileSize-8)
+	binary.LittleEndian.PutUint32(h[len(h)-8:], 4+w.moviBytes)
+	return h
+}
+
+// WriteVideo adds one RGBA frame of width by height pixels
+func (w *aviWriter) WriteVideo(pix []byte) error {
+	var data []byte
+	if w.codec == "mjpeg" {
+		img := &image.RGBA{Pix: pix, Stride: w.width * 4, Rect: image.Rect(0, 0, w.width, w.height)}
+		w.jpeg.Reset()
+		if err := jpeg.Encode(&w.jpeg, img, &jpeg.Options{Quality: aviJPEGQuality}); err != nil {
+			return err
+		}
+		data = w.jpeg.Bytes()
+	} else {
+		// Bottom-up BGR rows, padded to 4 bytes
+		stride := (w.width*3 + 3) &^ 3
+		size := stride * w.height
+		if cap(w.buf) < size {
+			w.buf = make([]byte, size)
+		}
+		w.buf = w.buf[:size]
+		for y := 0; y < w.height; y++ {
+			src := pix[y*w.width*4:]
+			dst := w.buf[(w.height-1-y)*stride:]
+			for x := 0; x < w.width; x++ {
+				dst[x*3+0] = src[x*4+2]
+				dst[x*3+1] = src[x*4+1]
+				dst[x*3+2] = src[x*4+0]
+			}
+		}
+		data = w.buf
+	}
+
+	if err := w.writeChunk("00dc", data); err != nil {
+		return err
+	}
+	w.frames++
+	return nil
+}
+
+// WriteAudio adds 16-bit stereo PCM data
+func (w *aviWriter) WriteAudio(pcm []byte) error {
+	if err := w.writeChunk("01wb", pcm); err != nil {
+		return err
+	}
+	w.audioBytes += uint32(len(pcm))
+	return nil
+}
+
+// writeChunk appends a chunk to the movi list and records it in the index
+func (w *aviWriter) writeChunk(id string, data []byte) error {
+	var h [8]byte
+	copy(h[:], id)
+	binary.LittleEndian.PutUint32(h[4:], uint32(len(data)))
+
+	w.index = append(w.index, aviIndexEntry{id: id, offset: 4 + w.moviBytes, size: uint32(len(data))})
+
+	if _, err := w.f.Write(h[:]); err != nil {
+		return err
+	}
+	if _, err := w.f.Write(data); err != nil {
+		return err
+	}
+	n := uint32(8 + len(data))
+	if len(data)%2 == 1 {
+		if _, err := w.f.Write([]byte{0}); err != nil {
+			return err
+		}
+		n++
+	}
+	w.moviBytes += n
+	w.maxChunk = max(w.maxChunk, uint32(len(data)))
+	return nil
+}
+
+// full reports whether the file is close to the AVI 1.0 size limit
+func (w *aviWriter) full() bool {
+	return w.moviBytes+uint32(len(w.index)*16) > aviMaxBytes
+}
+
+// Close writes the index, patches the header counts and closes the file
+func (w *aviWriter) Close() error {
+	idx := make([]byte, 8+len(w.index)*16)
+	copy(idx, "idx1")
+	binary.LittleEndian.PutUint32(idx[4:], uint32(len(w.index)*16))
+	for i, e := range w.index {
+		entry := idx[8+i*16:]
+		copy(entry, e.id)
+		binary.LittleEndian.PutUint32(entry[4:], aviKeyFrame)
+		binary.LittleEndian.PutUint32(entry[8:], e.offset)
+		binary.LittleEndian.PutUint32(entry[12:], e.size)
+	}
+	if _, err := w.f.Write(idx); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	if _, err := w.f.WriteAt(w.header(), 0); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
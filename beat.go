@@ -0,0 +1,137 @@
+// beat.go
+package main
+
+import (
+	"io"
+	"sync"
+)
+
+const (
+	beatWindow  = 1024 // samples per energy window (~23ms at 44.1kHz)
+	beatHistory = 43   // windows of history (~1s at 44.1kHz)
+	beatDecay   = 0.88 // per-frame decay of the beat level
+	beatFloor   = 1e-4 // minimum energy to count as an onset
+)
+
+// sampleSink receives mono samples in the range [-1, 1] from the music stream
+type sampleSink interface {
+	writeSamples(samples []float64)
+}
+
+// musicTap forwards the decoded PCM stream to the player and feeds a copy
+// to the analysers as it is read
+type musicTap struct {
+	src     io.ReadSeeker
+	sinks   []sampleSink
+	rest    []byte
+	samples []float64
+}
+
+// newMusicTap wraps a 16-bit stereo little-endian stream
+func newMusicTap(src io.ReadSeeker, sinks ...sampleSink) *musicTap {
+	return &musicTap{
+		src:   src,
+		sinks: sinks,
+	}
+}
+
+// Read reads from the source and hands complete frames to the sinks
+func (t *musicTap) Read(p []byte) (int, error) {
+	n, err := t.src.Read(p)
+	if n > 0 {
+		t.feed(p[:n])
+	}
+	return n, err
+}
+
+// Seek seeks the source and drops any partial frame
+func (t *musicTap) Seek(offset int64, whence int) (int64, error) {
+	t.rest = t.rest[:0]
+	return t.src.Seek(offset, whence)
+}
+
+// feed converts raw bytes to mono samples, keeping incomplete frames for later
+func (t *musicTap) feed(data []byte) {
+	buf := append(t.rest, data...)
+	frames := len(buf) / 4
+
+	t.samples = t.samples[:0]
+	for i := 0; i < frames; i++ {
+		l := int16(uint16(buf[i*4]) | uint16(buf[i*4+1])<<8)
+		r := int16(uint16(buf[i*4+2]) | uint16(buf[i*4+3])<<8)
+		t.samples = append(t.samples, (float64(l)+float64(r))/65536)
+	}
+	t.rest = append(t.rest[:0], buf[frames*4:]...)
+
+	for _, s := range t.sinks {
+		s.writeSamples(t.samples)
+	}
+}
+
+// beatDetector is an energy based onset detector: a window whose energy
+// exceeds the recent average by the sensitivity factor is a beat
+type beatDetector struct {
+	mu          sync.Mutex
+	sensitivity float64
+	sum         float64
+	count       int
+	history     [beatHistory]float64
+	pos         int
+	filled      int
+	pulse       float64
+	level       float64
+}
+
+// newBeatDetector creates a detector with the given sensitivity
+func newBeatDetector(sensitivity float64) *beatDetector {
+	return &beatDetector{sensitivity: sensitivity}
+}
+
+// writeSamples accumulates energy windows (called from the audio goroutine)
+func (b *beatDetector) writeSamples(samples []float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range samples {
+		b.sum += s * s
+		b.count++
+		if b.count < beatWindow {
+			continue
+		}
+
+		energy := b.sum / beatWindow
+		b.sum, b.count = 0, 0
+
+		avg := 0.0
+		for i := 0; i < b.filled; i++ {
+			avg += b.history[i]
+		}
+		if b.filled > 0 {
+			avg /= float64(b.filled)
+		}
+
+		if b.filled == beatHistory && energy > beatFloor && energy > avg*b.sensitivity {
+			strength := (energy/avg - b.sensitivity) / b.sensitivity
+			b.pulse = max(b.pulse, min(1, 0.6+strength))
+		}
+
+		b.history[b.pos] = energy
+		b.pos = (b.pos + 1) % beatHistory
+		if b.filled < beatHistory {
+			b.filled++
+		}
+	}
+}
+
+// tick advances the beat level by one frame and returns it
+func (b *beatDetector) tick() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.level *= beatDecay
+	if b.pulse > b.level {
+		b.level = b.pulse
+	}
+	b.pulse = 0
+	return b.level
+}
@@ -0,0 +1,136 @@
+// bloom.go
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const bloomDownscale = 4 // bloom buffers are a quarter of the output size
+
+// BloomConfig configures the bloom post-process, disabled when Intensity is 0
+type BloomConfig struct {
+	Intensity float64 `json:"intensity"`
+	Threshold float64 `json:"threshold"` // luminance above which pixels bloom, 0..1
+	Radius    float64 `json:"radius"`    // blur radius in native pixels
+}
+
+// Bright-pass shader source
+const brightShaderSrc = `//kage:unit pixels
+
+package main
+
+var Threshold float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var c vec4
+	c = imageSrc0At(srcPos)
+	var l float
+	l = dot(c.rgb, vec3(0.299, 0.587, 0.114))
+	var k float
+	k = max(l-Threshold, 0.0) / max(1.0-Threshold, 0.0001)
+	return c * k
+}
+`
+
+// Separable Gaussian blur shader source, run once per direction
+const blurShaderSrc = `//kage:unit pixels
+
+package main
+
+var Dir vec2
+var Radius float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var sigma float
+	sigma = max(Radius, 0.5) / 2.0
+	var sum vec4
+	var total float
+	for i := -8; i <= 8; i++ {
+		var o float
+		o = float(i) * Radius / 8.0
+		var w float
+		w = exp(-(o * o) / (2.0 * sigma * sigma))
+		sum += imageSrc0At(srcPos+Dir*o) * w
+		total += w
+	}
+	return sum / total
+}
+`
+
+// bloom holds the shaders and reduced resolution buffers of the bloom pass
+type bloom struct {
+	bright *ebiten.Shader
+	blur   *ebiten.Shader
+	small  *ebiten.Image
+	pingA  *ebiten.Image
+	pingB  *ebiten.Image
+}
+
+// newBloom compiles the bloom shaders
+func newBloom() (*bloom, error) {
+	bright, err := ebiten.NewShader([]byte(brightShaderSrc))
+	if err != nil {
+		return nil, err
+	}
+	blur, err := ebiten.NewShader([]byte(blurShaderSrc))
+	if err != nil {
+		return nil, err
+	}
+	return &bloom{bright: bright, blur: blur}, nil
+}
+
+// apply extracts the bright parts of dst, blurs them at reduced resolution
+// and adds them back onto dst, which is zoom times the native size
+func (b *bloom) apply(dst *ebiten.Image, cfg *BloomConfig, intensity float64, zoom int) {
+	w := max(1, dst.Bounds().Dx()/bloomDownscale)
+	h := max(1, dst.Bounds().Dy()/bloomDownscale)
+	if b.small == nil || b.small.Bounds().Dx() != w || b.small.Bounds().Dy() != h {
+		b.small = ebiten.NewImage(w, h)
+		b.pingA = ebiten.NewImage(w, h)
+		b.pingB = ebiten.NewImage(w, h)
+	}
+
+	// Downsample
+	b.small.Clear()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(1.0/bloomDownscale, 1.0/bloomDownscale)
+	op.Filter = ebiten.FilterLinear
+	b.small.DrawImage(dst, op)
+
+	// Bright pass
+	b.pingA.Clear()
+	shaderOp := &ebiten.DrawRectShaderOptions{}
+	shaderOp.Images[0] = b.small
+	shaderOp.Uniforms = map[string]any{
+		"Threshold": cfg.Threshold,
+	}
+	b.pingA.DrawRectShader(w, h, b.bright, shaderOp)
+
+	// Horizontal then vertical blur, radius scaled to the buffer resolution
+	radius := cfg.Radius * float64(zoom) / bloomDownscale
+	for _, pass := range []struct {
+		dir      []float32
+		src, dst *ebiten.Image
+	}{
+		{[]float32{1, 0}, b.pingA, b.pingB},
+		{[]float32{0, 1}, b.pingB, b.pingA},
+	} {
+		pass.dst.Clear()
+		shaderOp := &ebiten.DrawRectShaderOptions{}
+		shaderOp.Images[0] = pass.src
+		shaderOp.Uniforms = map[string]any{
+			"Dir":    pass.dir,
+			"Radius": radius,
+		}
+		pass.dst.DrawRectShader(w, h, b.blur, shaderOp)
+	}
+
+	// Additive composite
+	op = &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(dst.Bounds().Dx())/float64(w), float64(dst.Bounds().Dy())/float64(h))
+	op.Filter = ebiten.FilterLinear
+	op.Blend = ebiten.BlendLighter
+	k := float32(intensity)
+	op.ColorScale.Scale(k, k, k, k)
+	dst.DrawImage(b.pingA, op)
+}
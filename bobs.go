@@ -0,0 +1,110 @@
+// bobs.go
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	bobRadius    = 90.0               // default object radius at the overscan height
+	bobDistance  = 260.0              // camera distance from the object centre
+	bobMaxRadius = 0.75 * bobDistance // keeps the nearest points well in front of the camera
+)
+
+// BobsConfig configures the 3D bob mode of the sprites
+type BobsConfig struct {
+	Shape  string     `json:"shape"`  // "sphere", "torus" or "cube"
+	Speed  [3]float64 `json:"speed"`  // rotation per tick around x, y, z in radians
+	Radius float64    `json:"radius"` // object radius in native pixels, 0 scales the default with the resolution
+}
+
+// bobPoint is a point of the bob object
+type bobPoint struct {
+	x, y, z float64
+}
+
+// bobShape generates n points on the configured shape, within a unit radius
+func bobShape(shape string, n int) []bobPoint {
+	points := make([]bobPoint, 0, n)
+
+	switch shape {
+	case "torus":
+		// Points wound around the tube of the torus
+		for i := 0; i < n; i++ {
+			u := 2 * math.Pi * float64(i) / float64(n)
+			v := u * 7
+			r := 0.7 + 0.3*math.Cos(v)
+			points = append(points, bobPoint{r * math.Cos(u), 0.3 * math.Sin(v), r * math.Sin(u)})
+		}
+
+	case "cube":
+		// Smallest lattice holding n points, filled from the corners inwards
+		side := max(2, int(math.Ceil(math.Cbrt(float64(n)))))
+		var lattice []bobPoint
+		for x := 0; x < side; x++ {
+			for y := 0; y < side; y++ {
+				for z := 0; z < side; z++ {
+					p := func(i int) float64 { return 2*float64(i)/float64(side-1) - 1 }
+					lattice = append(lattice, bobPoint{p(x) * 0.6, p(y) * 0.6, p(z) * 0.6})
+				}
+			}
+		}
+		sort.SliceStable(lattice, func(i, j int) bool {
+			a, b := lattice[i], lattice[j]
+			return a.x*a.x+a.y*a.y+a.z*a.z > b.x*b.x+b.y*b.y+b.z*b.z
+		})
+		points = append(points, lattice[:n]...)
+
+	default:
+		// Fibonacci sphere for an even spread
+		golden := math.Pi * (3 - math.Sqrt(5))
+		for i := 0; i < n; i++ {
+			y := 1 - 2*(float64(i)+0.5)/float64(n)
+			r := math.Sqrt(1 - y*y)
+			a := golden * float64(i)
+			points = append(points, bobPoint{r * math.Cos(a), y, r * math.Sin(a)})
+		}
+	}
+	return points
+}
+
+// updateBobs rotates the bob object, projects the sprites and sorts them
+// back to front
+func (g *Game) updateBobs() {
+	cfg := &g.config.Bobs
+	if len(g.bobPoints) != len(g.sprites) {
+		g.bobPoints = bobShape(cfg.Shape, len(g.sprites))
+	}
+
+	ax := g.ctrSprite * cfg.Speed[0]
+	ay := g.ctrSprite * cfg.Speed[1]
+	az := g.ctrSprite * cfg.Speed[2]
+	sx, cx := math.Sincos(ax)
+	sy, cy := math.Sincos(ay)
+	sz, cz := math.Sincos(az)
+
+	for i, p := range g.bobPoints {
+		x, y, z := p.x*cfg.Radius, p.y*cfg.Radius, p.z*cfg.Radius
+
+		// Rotate around x, y then z
+		y, z = y*cx-z*sx, y*sx+z*cx
+		x, z = x*cy+z*sy, -x*sy+z*cy
+		x, y = x*cz-y*sz, x*sz+y*cz
+
+		// Perspective projection
+		persp := bobDistance / (bobDistance + z)
+		sprite := g.sprites[i]
+		sprite.x = float64(g.screenW)/2 + x*persp
+		sprite.y = float64(g.screenH)/2 + y*persp
+		sprite.z = z
+		sprite.scale = persp
+		sprite.frame = g.spriteFrame(sprite, g.ctrSprite)
+	}
+
+	// Painter's algorithm, farthest first
+	g.drawOrder = append(g.drawOrder[:0], g.sprites...)
+	sort.SliceStable(g.drawOrder, func(i, j int) bool {
+		return g.drawOrder[i].z > g.drawOrder[j].z
+	})
+}
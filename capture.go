@@ -0,0 +1,172 @@
+// capture.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const captureTPS = 60
+
+// captureOptions holds the command line capture settings
+type captureOptions struct {
+	wavPath string
+	aviPath string
+	codec   string // "raw" or "mjpeg"
+	scale   int    // output frame scale of the recorded video
+	frames  int
+
+	gifPath  string
+	gifStart int // first tick of the GIF
+	gifEnd   int // tick after the last one of the GIF, 0 for no GIF
+}
+
+// offline reports whether the demo renders to files instead of playing live
+func (c *captureOptions) offline() bool {
+	return c.wavPath != "" || c.aviPath != "" || c.gifEnd > 0
+}
+
+// parseTickRange parses a "start,end" pair of tick indices
+func parseTickRange(s string) (int, int, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(s, "%d,%d", &start, &end); err != nil {
+		return 0, 0, fmt.Errorf("invalid tick range %q, expected start,end", s)
+	}
+	if start < 0 || end <= start {
+		return 0, 0, fmt.Errorf("invalid tick range %q, end must be after start", s)
+	}
+	return start, end, nil
+}
+
+// startCapture opens the offline outputs and the music stream they pull from
+func (g *Game) startCapture() error {
+	// A GIF ends the capture after its last tick
+	if g.capture.gifEnd > 0 && (g.capture.frames <= 0 || g.capture.frames > g.capture.gifEnd) {
+		g.capture.frames = g.capture.gifEnd
+	}
+	if g.capture.frames <= 0 && g.config.ScrollEnd != "fadeout" {
+		return fmt.Errorf("-frames must be positive when capturing, unless scrollEnd is fadeout")
+	}
+	if g.capture.codec != "raw" && g.capture.codec != "mjpeg" {
+		return fmt.Errorf("unknown codec %q, use raw or mjpeg", g.capture.codec)
+	}
+
+	// Run one tick per frame as fast as possible instead of in real time
+	ebiten.SetTPS(ebiten.SyncWithFPS)
+	ebiten.SetVsyncEnabled(false)
+
+	stream, err := g.openTrack(0)
+	if err != nil {
+		log.Printf("Warning: Could not load music, capturing silence: %v", err)
+		stream = nil
+	}
+	g.offline = newAudioRenderer(stream, g.audioContext.SampleRate(), captureTPS)
+
+	if g.capture.wavPath != "" {
+		g.wavOut, err = newWAVWriter(g.capture.wavPath, g.audioContext.SampleRate(), 2)
+		if err != nil {
+			return err
+		}
+	}
+	g.capture.scale = max(1, g.capture.scale)
+	w, h := g.screenW*g.capture.scale, g.screenH*g.capture.scale
+	if g.capture.gifEnd > 0 {
+		g.gifOut = newGIFRecorder(g.capture.gifPath, g.capture.gifStart, g.capture.gifEnd, w, h)
+	}
+	if g.capture.aviPath != "" {
+		g.aviOut, err = newAVIWriter(g.capture.aviPath, g.capture.codec, w, h, captureTPS, g.audioContext.SampleRate())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// captureFrame pulls one tick of audio and returns ebiten.Termination once
+// the requested number of frames has been rendered
+func (g *Game) captureFrame() error {
+	if g.recordErr != nil {
+		return g.recordErr
+	}
+	if g.capture.frames > 0 && g.frame >= g.capture.frames {
+		if err := g.stopCapture(); err != nil {
+			return err
+		}
+		return ebiten.Termination
+	}
+
+	pcm := g.offline.next()
+	if g.wavOut != nil {
+		if _, err := g.wavOut.Write(pcm); err != nil {
+			return err
+		}
+	}
+	if g.aviOut != nil {
+		if err := g.aviOut.WriteAudio(pcm); err != nil {
+			return err
+		}
+	}
+
+	g.frame++
+	return nil
+}
+
+// recordFrame appends the finished output frame of the last tick to the
+// video and GIF, ending the capture early when the AVI reaches its size limit
+func (g *Game) recordFrame(frame *ebiten.Image) error {
+	tick := g.frame - 1
+	wantGIF := g.gifOut != nil && g.gifOut.wants(tick)
+	if g.aviOut == nil && !wantGIF {
+		return nil
+	}
+
+	b := frame.Bounds()
+	if len(g.recordPixels) != b.Dx()*b.Dy()*4 {
+		g.recordPixels = make([]byte, b.Dx()*b.Dy()*4)
+	}
+	frame.ReadPixels(g.recordPixels)
+	if wantGIF {
+		g.gifOut.add(g.recordPixels)
+	}
+	if g.aviOut == nil {
+		return nil
+	}
+	if err := g.aviOut.WriteVideo(g.recordPixels); err != nil {
+		return err
+	}
+
+	if g.aviOut.full() && (g.capture.frames <= 0 || g.capture.frames > g.frame) {
+		log.Printf("Warning: AVI size limit reached after %d frames, stopping", g.frame)
+		g.capture.frames = g.frame
+	}
+	return nil
+}
+
+// stopCapture finalizes the output files
+func (g *Game) stopCapture() error {
+	if g.wavOut != nil {
+		if err := g.wavOut.Close(); err != nil {
+			return err
+		}
+		log.Printf("Wrote %d frames of audio to %s", g.frame, g.capture.wavPath)
+		g.wavOut = nil
+	}
+	if g.aviOut != nil {
+		if err := g.aviOut.Close(); err != nil {
+			return err
+		}
+		log.Printf("Wrote %d frames of %s video to %s", g.aviOut.frames, g.capture.codec, filepath.Base(g.capture.aviPath))
+		g.aviOut = nil
+	}
+	if g.gifOut != nil {
+		if err := g.gifOut.Close(); err != nil {
+			return err
+		}
+		log.Printf("Wrote %d frames to %s", len(g.gifOut.frames), g.capture.gifPath)
+		g.gifOut = nil
+	}
+	return nil
+}
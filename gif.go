@@ -0,0 +1,192 @@
+// gif.go
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"sort"
+)
+
+const (
+	gifFrameStep = 2   // keep every other tick, delays under 2/100 s play back unreliably
+	gifColors    = 256 // palette size
+	gifBits      = 5   // bits per channel of the colour histogram
+)
+
+// gifRecorder collects the frames of a tick range and writes them as an
+// animated GIF with one median cut palette
+type gifRecorder struct {
+	path   string
+	start  int
+	end    int
+	width  int
+	height int
+	frames [][]uint16 // histogram colour per pixel
+	hist   []int      // pixel count per histogram colour
+	sums   [][3]int   // channel sums per histogram colour, for exact averages
+}
+
+// newGIFRecorder records the ticks in [start, end) of width by height frames
+func newGIFRecorder(path string, start, end, width, height int) *gifRecorder {
+	return &gifRecorder{
+		path:   path,
+		start:  start,
+		end:    end,
+		width:  width,
+		height: height,
+		hist:   make([]int, 1<<(3*gifBits)),
+		sums:   make([][3]int, 1<<(3*gifBits)),
+	}
+}
+
+// wants reports whether the frame of a tick belongs in the GIF
+func (r *gifRecorder) wants(tick int) bool {
+	return tick >= r.start && tick < r.end && (tick-r.start)%gifFrameStep == 0
+}
+
+// add stores the histogram colours of an RGBA frame and counts them
+func (r *gifRecorder) add(pix []byte) {
+	frame := make([]uint16, len(pix)/4)
+	for p := range frame {
+		i := p * 4
+		key := gifKey(pix[i], pix[i+1], pix[i+2])
+		frame[p] = uint16(key)
+		r.hist[key]++
+		r.sums[key][0] += int(pix[i])
+		r.sums[key][1] += int(pix[i+1])
+		r.sums[key][2] += int(pix[i+2])
+	}
+	r.frames = append(r.frames, frame)
+}
+
+// gifKey returns the histogram colour of an RGB value
+func gifKey(r, g, b uint8) int {
+	const shift = 8 - gifBits
+	return int(r>>shift)<<(2*gifBits) | int(g>>shift)<<gifBits | int(b>>shift)
+}
+
+// gifEntry is a histogram colour with its pixel count
+type gifEntry struct {
+	key   int
+	c     [3]int
+	count int
+}
+
+// medianCut splits the histogram colours into at most n boxes, cutting the
+// box with the widest channel at its weighted median, and returns the
+// weighted average colour of each box
+func medianCut(entries []gifEntry, n int) color.Palette {
+	boxes := [][]gifEntry{entries}
+
+	for len(boxes) < n {
+		// Box with the widest channel range
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 3; ch++ {
+				lo, hi := box[0].c[ch], box[0].c[ch]
+				for _, e := range box {
+					lo, hi = min(lo, e.c[ch]), max(hi, e.c[ch])
+				}
+				if hi-lo > bestRange {
+					best, bestChannel, bestRange = i, ch, hi-lo
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		sort.Slice(box, func(i, j int) bool { return box[i].c[bestChannel] < box[j].c[bestChannel] })
+		total := 0
+		for _, e := range box {
+			total += e.count
+		}
+		cut, acc := 1, 0
+		for i, e := range box[:len(box)-1] {
+			acc += e.count
+			cut = i + 1
+			if acc*2 >= total {
+				break
+			}
+		}
+		boxes[best] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var sum [3]int
+		total := 0
+		for _, e := range box {
+			for ch := range sum {
+				sum[ch] += e.c[ch] * e.count
+			}
+			total += e.count
+		}
+		palette = append(palette, color.RGBA{
+			uint8(sum[0] / total), uint8(sum[1] / total), uint8(sum[2] / total), 255,
+		})
+	}
+	return palette
+}
+
+// Close builds the palette and writes the GIF
+func (r *gifRecorder) Close() error {
+	if len(r.frames) == 0 {
+		return fmt.Errorf("no frames between ticks %d and %d", r.start, r.end)
+	}
+
+	// Average colour of each histogram cell
+	var entries []gifEntry
+	for key, count := range r.hist {
+		if count == 0 {
+			continue
+		}
+		sum := r.sums[key]
+		entries = append(entries, gifEntry{
+			key:   key,
+			c:     [3]int{sum[0] / count, sum[1] / count, sum[2] / count},
+			count: count,
+		})
+	}
+	if len(entries) == 0 {
+		entries = append(entries, gifEntry{count: 1})
+	}
+	palette := medianCut(entries, gifColors)
+
+	// Nearest palette entry of every histogram colour
+	lut := make([]uint8, len(r.hist))
+	for _, e := range entries {
+		lut[e.key] = uint8(palette.Index(color.RGBA{uint8(e.c[0]), uint8(e.c[1]), uint8(e.c[2]), 255}))
+	}
+
+	anim := &gif.GIF{}
+	for i, frame := range r.frames {
+		img := image.NewPaletted(image.Rect(0, 0, r.width, r.height), palette)
+		for p, key := range frame {
+			img.Pix[p] = lut[key]
+		}
+
+		// Round the running time so the delays average out to the tick rate
+		delay := func(n int) int { return (n*gifFrameStep*100 + captureTPS/2) / captureTPS }
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, delay(i+1)-delay(i))
+	}
+
+	f, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
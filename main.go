@@ -4,6 +4,7 @@ package main
 import (
 	"embed"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -11,20 +12,21 @@ import (
 	"math"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
-	screenWidth  = 416
-	screenHeight = 276
-	zoom         = 2
 	backHeight   = 64
 	fontHeight   = 36
 	spriteSize   = 32
+	fadeOutTicks = 120
+	introY       = 170 // intro scroller line on the overscan screen
 )
 
 // Wave types
@@ -54,7 +56,69 @@ type Config struct {
 	SpriteCount    int     `json:"spriteCount"`
 	DistortionRate float64 `json:"distortionRate"`
 	EnableCRT      bool    `json:"enableCRT"`
-	EnableGlow     bool    `json:"enableGlow"`
+
+	// CRT
+	CRT CRTConfig `json:"crt"`
+
+	// Bloom, enableGlow is the setting it replaced and only disables it
+	Bloom      BloomConfig `json:"bloom"`
+	EnableGlow *bool       `json:"enableGlow,omitempty"`
+
+	// Internal resolution and window scaling
+	Resolution string `json:"resolution"` // "stlow", "overscan" or "fulloverscan"
+	Scaling    string `json:"scaling"`    // "integer", "fit", "stretch" or "st"
+
+	// Post-processing chain, derived from enableCRT and bloom when absent
+	Post []PostStageConfig `json:"post"`
+
+	// Atari ST colour emulation of the native frame
+	STMode STModeConfig `json:"stMode"`
+
+	// Sprite animation
+	SpriteSheet   SpriteSheetConfig `json:"spriteSheet"`
+	SpritesNative bool              `json:"spritesNative"` // compose sprites at native resolution
+
+	// Sprite mode
+	SpriteMode string       `json:"spriteMode"` // "path" or "bobs"
+	Bobs       BobsConfig   `json:"bobs"`
+	Trails     TrailsConfig `json:"trails"`
+
+	// Sprite paths
+	SpritePath     string                 `json:"spritePath"`
+	Trajectories   map[string]*Trajectory `json:"trajectories"`
+	SpriteTimeline []TrajectoryCue        `json:"spriteTimeline"`
+
+	// Parts
+	Parts       []string           `json:"parts"`
+	ScrollEnd   string             `json:"scrollEnd"` // "loop", "restart", "fadeout" or "next"
+	Transitions []TransitionConfig `json:"transitions"`
+	Splash      SplashConfig       `json:"splash"`
+
+	// Raster colours
+	Rasters []RasterConfig `json:"rasters"`
+
+	// Music
+	Playlist []string `json:"playlist"`
+
+	// Screenshots (F12)
+	ScreenshotDir string `json:"screenshotDir"`
+
+	// Beat sync
+	BeatSensitivity float64 `json:"beatSensitivity"`
+	BeatBounce      bool    `json:"beatBounce"`
+	BeatGlow        bool    `json:"beatGlow"`
+	BeatVignette    bool    `json:"beatVignette"`
+
+	// Overlays
+	Analyser AnalyserConfig `json:"analyser"`
+}
+
+// CRTConfig holds the CRT shader parameters
+type CRTConfig struct {
+	Barrel    float64 `json:"barrel"`
+	Scanlines float64 `json:"scanlines"` // scanline intensity
+	RGBShift  float64 `json:"rgbShift"`  // fraction of the width
+	Vignette  float64 `json:"vignette"`
 }
 
 // Letter represents a character in the font
@@ -67,7 +131,11 @@ type Letter struct {
 // Sprite represents a logo sprite
 type Sprite struct {
 	x, y  float64
+	z     float64 // depth in bob mode, positive is away from the viewer
+	scale float64
 	index int
+	frame *ebiten.Image
+	tint  color.RGBA
 }
 
 // Delete PrecomputedFrame as it's no longer needed
@@ -79,8 +147,12 @@ type Game struct {
 	fontImg  *ebiten.Image
 	logoImg  *ebiten.Image
 
+	splashImg    *ebiten.Image
+	spriteFrames []*ebiten.Image
+
 	// Surfaces
 	surfMain    *ebiten.Image
+	surfComp    *ebiten.Image
 	surfScroll  *ebiten.Image
 	surfBack    *ebiten.Image
 	surfScroll1 *ebiten.Image
@@ -89,9 +161,33 @@ type Game struct {
 	// Audio
 	audioContext *audio.Context
 	audioPlayer  *audio.Player
+	beat         *beatDetector
+	beatLevel    float64
+	analyser     *analyser
+	track        int
+	trackLength  time.Duration
+	volume       float64
+	muted        bool
+	paused       bool
+	volumeOSD    int
+	audioError   string
+
+	// Capture
+	capture captureOptions
+	offline *audioRenderer
+	wavOut  *wavWriter
+	aviOut  *aviWriter
+	gifOut  *gifRecorder
+	frame   int
+
+	recordPixels []byte
+	recordErr    error
+
+	screenshotPending bool
 
 	// State
-	state        string // "intro", "splash", "demo"
+	seq          *sequencer
+	ticks        int // ticks simulated since the start
 	iteration    int
 	backWavePos  int
 	frontWavePos int
@@ -106,7 +202,16 @@ type Game struct {
 
 	// Sprites
 	sprites   []*Sprite
-	ctrSprite float64
+	ctrSprite float64 // ticks, scaled by the trajectory speed
+	bobPoints []bobPoint
+	drawOrder []*Sprite
+
+	// Sprite trails
+	trailFront   *ebiten.Image
+	trailBack    *ebiten.Image
+	trailHistory [][]Sprite // ring of the last Length snapshots
+	trailNext    int        // slot of the next snapshot
+	trailCount   int        // snapshots in the ring
 
 	// Wave tables
 	backIntroWaveTable  []int
@@ -121,6 +226,13 @@ type Game struct {
 	frontIntroWave  []int
 	frontMainWave   []int
 	position        []int
+	splashWave      []int
+
+	// Raster colours per native line, nil when no raster targets the layer
+	rasters      []*raster
+	rasterBack   []color.RGBA
+	rasterFont   []color.RGBA
+	rasterBehind []color.RGBA
 
 	// Font data
 	letterData map[rune]*Letter
@@ -133,25 +245,54 @@ type Game struct {
 	config *Config
 
 	// Shaders
-	crtShader *ebiten.Shader
+	dissolveShader *ebiten.Shader
+	post           []postStage
+	st             *stMode
+
+	// Output frame before and after post-processing and after transitions,
+	// rendered at zoom times the native size
+	surfScaled  *ebiten.Image
+	surfPing    *ebiten.Image
+	surfOut     *ebiten.Image
+	surfView    *ebiten.Image
+	surfHistory *ebiten.Image // previous output of the phosphor stage
+	historyTick int           // tick whose output is in surfHistory
+
+	// Internal resolution and scaling
+	screenW  int
+	screenH  int
+	zoom     int
+	viewport viewport
 
 	// Transition
 	transitionProgress float64
-	lastState          string
+	fadeOut            int
 }
 
 // CRT shader source
-const crtShaderSrc = `
+const crtShaderSrc = `//kage:unit pixels
+
 package main
 
-func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+var Barrel float
+var Scanlines float
+var ScanlineFreq float
+var RGBShift float
+var Vignette float
+
+func Fragment(position vec4, srcPos vec2, color vec4) vec4 {
+	var origin vec2
+	var size vec2
+	origin = imageSrc0Origin()
+	size = imageSrc0Size()
+
 	var uv vec2
-	uv = texCoord
+	uv = (srcPos - origin) / size
 
 	// Barrel distortion
 	var dc vec2
 	dc = uv - 0.5
-	dc = dc * (1.0 + dot(dc, dc) * 0.15)
+	dc = dc * (1.0 + dot(dc, dc) * Barrel)
 	uv = dc + 0.5
 
 	// Check bounds
@@ -160,25 +301,25 @@ func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
 	}
 
 	// Sample texture
+	var pos vec2
+	pos = origin + uv*size
 	var col vec4
-	col = imageSrc0At(uv)
+	col = imageSrc0At(pos)
+
+	// RGB shift
+	var shift vec2
+	shift = vec2(RGBShift*size.x, 0.0)
+	col.r = imageSrc0At(pos + shift).r
+	col.b = imageSrc0At(pos - shift).b
 
 	// Scanlines
 	var scanline float
-	scanline = sin(uv.y * 800.0) * 0.04
+	scanline = sin(uv.y * ScanlineFreq) * Scanlines
 	col.rgb = col.rgb - scanline
 
-	// RGB shift
-	var rShift float
-	var bShift float
-	rShift = imageSrc0At(uv + vec2(0.002, 0.0)).r
-	bShift = imageSrc0At(uv - vec2(0.002, 0.0)).b
-	col.r = rShift
-	col.b = bShift
-
 	// Vignette
 	var vignette float
-	vignette = 1.0 - dot(dc, dc) * 0.5
+	vignette = 1.0 - dot(dc, dc) * Vignette
 	col.rgb = col.rgb * vignette
 
 	return col * color
@@ -188,24 +329,25 @@ func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
 // NewGame creates a new game instance
 func NewGame() *Game {
 	g := &Game{
-		state:       "intro",
 		introX:      -1,
 		introLetter: -1,
 		introTile:   -1,
 		introSpeed:  4,
 		letterData:  make(map[rune]*Letter),
-		lastState:   "",
 	}
 
 	// Load config
 	g.config = g.loadConfig()
+	size := resolutions[g.config.Resolution]
+	g.screenW, g.screenH = size[0], size[1]
 
 	// Initialize sprites based on config
 	g.sprites = make([]*Sprite, g.config.SpriteCount)
 	for i := 0; i < g.config.SpriteCount; i++ {
 		g.sprites[i] = &Sprite{
-			x:     float64(screenWidth) / 2,
-			y:     float64(screenHeight) / 2,
+			x:     float64(g.screenW) / 2,
+			y:     float64(g.screenH) / 2,
+			scale: 1,
 			index: i,
 		}
 	}
@@ -261,14 +403,78 @@ func (g *Game) loadConfig() *Config {
 			Fullscreen:     false,
 			VSync:          true,
 			MusicVolume:    0.7,
+			Playlist:       []string{"assets/music.mp3"},
 			SpriteCount:    10,
 			DistortionRate: 1.0,
 			EnableCRT:      true,
-			EnableGlow:     true,
+
+			CRT: CRTConfig{
+				Barrel:    0.15,
+				Scanlines: 0.04,
+				RGBShift:  0.002,
+				Vignette:  0.5,
+			},
+
+			Bloom: BloomConfig{
+				Intensity: 0.8,
+				Threshold: 0.6,
+				Radius:    6,
+			},
+
+			SpriteSheet: SpriteSheetConfig{
+				FPS:  30,
+				Mode: "loop",
+			},
+
+			SpriteMode: "path",
+			Bobs: BobsConfig{
+				Shape:  "sphere",
+				Speed:  [3]float64{0.011, 0.017, 0.005},
+				Radius: bobRadius,
+			},
+			Trails: TrailsConfig{
+				Decay:  0.08,
+				Length: 8,
+			},
+
+			SpritePath: "classic",
+
+			Resolution: "overscan",
+			Scaling:    "integer",
+
+			ScreenshotDir: "screenshots",
+
+			Parts:     []string{"intro", "splash", "demo"},
+			ScrollEnd: "loop",
+			Transitions: []TransitionConfig{
+				{From: "intro", To: "splash", Effect: "fade", Duration: 30},
+				{From: "splash", To: "demo", Effect: "raster", Duration: 60, Easing: "out"},
+			},
+			Splash: SplashConfig{
+				Logo:      "assets/logo.png",
+				Scale:     4,
+				Effect:    "raster",
+				GroupName: "DMA",
+				Duration:  180,
+			},
+
+			BeatSensitivity: 1.4,
+
+			Analyser: AnalyserConfig{
+				X:      8,
+				Y:      8,
+				Width:  96,
+				Height: 36,
+				Bands:  16,
+			},
 		}
 	}
 
-	var cfg Config
+	// Settings whose zero value is meaningful default before decoding
+	cfg := Config{
+		CRT:   CRTConfig{Barrel: 0.15, Scanlines: 0.04, RGBShift: 0.002, Vignette: 0.5},
+		Bloom: BloomConfig{Intensity: 0.8},
+	}
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return g.loadConfig() // Return default on parse error
 	}
@@ -283,6 +489,80 @@ func (g *Game) loadConfig() *Config {
 	if cfg.MusicVolume < 0 || cfg.MusicVolume > 1 {
 		cfg.MusicVolume = 0.7
 	}
+	if len(cfg.Parts) == 0 {
+		cfg.Parts = []string{"intro", "splash", "demo"}
+	}
+	if cfg.SpriteSheet.FPS <= 0 {
+		cfg.SpriteSheet.FPS = 30
+	}
+	if cfg.SpriteMode != "bobs" {
+		cfg.SpriteMode = "path"
+	}
+	if cfg.Trails.Decay <= 0 || cfg.Trails.Decay > 1 {
+		cfg.Trails.Decay = 0.08
+	}
+	if cfg.Trails.Length <= 0 {
+		cfg.Trails.Length = 8
+	}
+	if cfg.SpritePath == "" {
+		cfg.SpritePath = "classic"
+	}
+	switch cfg.ScrollEnd {
+	case "loop", "restart", "fadeout", "next":
+	default:
+		cfg.ScrollEnd = "loop"
+	}
+	if cfg.Splash.Scale <= 0 {
+		cfg.Splash.Scale = 4
+	}
+	if cfg.Splash.Duration <= 0 {
+		cfg.Splash.Duration = 180
+	}
+	if cfg.ScreenshotDir == "" {
+		cfg.ScreenshotDir = "screenshots"
+	}
+	if len(cfg.Playlist) == 0 {
+		cfg.Playlist = []string{"assets/music.mp3"}
+	}
+	if _, ok := resolutions[cfg.Resolution]; !ok {
+		cfg.Resolution = "overscan"
+	}
+	if cfg.Bobs.Radius <= 0 {
+		cfg.Bobs.Radius = bobRadius * float64(resolutions[cfg.Resolution][1]) / overscanHeight
+	}
+	if cfg.Bobs.Radius > bobMaxRadius {
+		log.Printf("Warning: Bobs radius %g clamped to %g", cfg.Bobs.Radius, bobMaxRadius)
+		cfg.Bobs.Radius = bobMaxRadius
+	}
+	switch cfg.Scaling {
+	case "integer", "fit", "stretch", "st":
+	default:
+		cfg.Scaling = "integer"
+	}
+	if cfg.STMode.Depth != "st" && cfg.STMode.Depth != "ste" {
+		cfg.STMode.Depth = "st"
+	}
+	if cfg.Bloom.Intensity < 0 || (cfg.EnableGlow != nil && !*cfg.EnableGlow) {
+		cfg.Bloom.Intensity = 0
+	}
+	if cfg.Bloom.Threshold < 0 || cfg.Bloom.Threshold >= 1 {
+		cfg.Bloom.Threshold = 0.6
+	}
+	if cfg.Bloom.Radius <= 0 {
+		cfg.Bloom.Radius = 6
+	}
+	if cfg.BeatSensitivity <= 1 {
+		cfg.BeatSensitivity = 1.4
+	}
+	if cfg.Analyser.Width <= 0 {
+		cfg.Analyser.Width = 96
+	}
+	if cfg.Analyser.Height <= 0 {
+		cfg.Analyser.Height = 36
+	}
+	if cfg.Analyser.Bands <= 0 || cfg.Analyser.Bands > 64 {
+		cfg.Analyser.Bands = 16
+	}
 
 	return &cfg
 }
@@ -322,11 +602,13 @@ func (g *Game) Init() error {
 	}
 
 	// Create surfaces
-	g.surfMain = ebiten.NewImage(screenWidth, screenHeight)
-	g.surfScroll = ebiten.NewImage(int(math.Round(screenWidth*1.6)), fontHeight)
-	g.surfBack = ebiten.NewImage(screenWidth+256, backHeight) // More width for distortion
-	g.surfScroll1 = ebiten.NewImage(screenWidth+48, fontHeight)
-	g.surfScroll2 = ebiten.NewImage(screenWidth+48, fontHeight)
+	g.surfMain = ebiten.NewImage(g.screenW, g.screenH)
+	g.surfComp = ebiten.NewImage(g.screenW, g.screenH)
+	g.surfScroll = ebiten.NewImage(int(math.Round(float64(g.screenW)*1.6)), fontHeight)
+	g.surfBack = ebiten.NewImage(g.screenW+256, backHeight) // More width for distortion
+	g.surfScroll1 = ebiten.NewImage(g.screenW+48, fontHeight)
+	g.surfScroll2 = ebiten.NewImage(g.screenW+48, fontHeight)
+	g.updateViewport(g.windowSize())
 
 	// Initialize font data
 	g.initFontData()
@@ -342,6 +624,19 @@ func (g *Game) Init() error {
 	g.precalcWave(g.backIntroWaveTable, &g.backIntroWave)
 	g.precalcWave(g.backMainWaveTable, &g.backMainWave)
 
+	// Load splash logo and sprite animation
+	g.loadSplash()
+	g.loadSpriteSheet()
+
+	// Check sprite paths
+	g.validateTrajectories()
+
+	// Parse raster colours
+	g.loadRasters()
+
+	// Build the part sequence
+	g.seq = newSequencer(g.config.Parts)
+
 	// Prepare background surface
 	g.surfBack.Clear()
 	// Fill the entire background surface with tiled background image
@@ -353,44 +648,38 @@ func (g *Game) Init() error {
 
 	// Initialize audio
 	g.audioContext = audio.NewContext(44100)
-
-	// Load and play music
-	if err := g.loadMusic(); err != nil {
-		log.Printf("Warning: Could not load music: %v", err)
+	if g.config.Analyser.Enabled {
+		g.analyser = newAnalyser(&g.config.Analyser, g.audioContext.SampleRate())
 	}
 
-	// Compile CRT shader if enabled
-	if g.config.EnableCRT {
-		g.crtShader, err = ebiten.NewShader([]byte(crtShaderSrc))
-		if err != nil {
-			log.Printf("Warning: Could not compile CRT shader: %v", err)
-			g.config.EnableCRT = false
+	// Load and play music, or pull it tick by tick when capturing
+	g.volume = g.config.MusicVolume
+	g.beat = newBeatDetector(g.config.BeatSensitivity)
+	if g.capture.offline() {
+		if err := g.startCapture(); err != nil {
+			return err
 		}
+	} else if err := g.playTrack(0); err != nil {
+		log.Printf("Warning: Could not load music: %v", err)
 	}
 
-	return nil
-}
+	// Compile the post-processing chain
+	g.buildPostChain()
 
-// loadMusic loads and plays the music file
-func (g *Game) loadMusic() error {
-	musicData, err := assets.ReadFile("assets/music.mp3")
-	if err != nil {
-		return err
-	}
-
-	d, err := mp3.DecodeWithSampleRate(g.audioContext.SampleRate(), strings.NewReader(string(musicData)))
-	if err != nil {
-		return err
+	// Prepare the ST colour emulation
+	if g.config.STMode.Enabled {
+		g.st, err = newSTMode(&g.config.STMode)
+		if err != nil {
+			log.Printf("Warning: ST mode disabled: %v", err)
+		}
 	}
 
-	loop := audio.NewInfiniteLoop(d, d.Length())
-	g.audioPlayer, err = g.audioContext.NewPlayer(loop)
+	// Compile transition shaders
+	g.dissolveShader, err = ebiten.NewShader([]byte(dissolveShaderSrc))
 	if err != nil {
-		return err
+		log.Printf("Warning: Could not compile dissolve shader: %v", err)
 	}
 
-	g.audioPlayer.SetVolume(g.config.MusicVolume)
-	g.audioPlayer.Play()
 	return nil
 }
 
@@ -605,14 +894,19 @@ func (g *Game) getWave(i int, introWave, mainWave []int) int {
 	return g.getSum(mainWave, i-len(introWave), introWave[len(introWave)-1])
 }
 
-// getPosition gets text position
+// getPosition gets text position, wrapping past the end of the text
 func (g *Game) getPosition(i int) int {
-	if i > 0 && i <= len(g.position) {
+	if i > 0 {
 		return g.getSum(g.position, i-1, 0)
 	}
 	return 0
 }
 
+// scrollEnded reports whether the whole text has scrolled past
+func (g *Game) scrollEnded() bool {
+	return len(g.position) > 0 && g.letterNum >= len(g.position)
+}
+
 // getLetter gets letter at position
 func (g *Game) getLetter(str string, pos int) rune {
 	runes := []rune(str)
@@ -642,64 +936,60 @@ func (g *Game) displayText(letterOffset int) {
 	}
 }
 
-// updateSprites updates sprite positions
+// updateSprites updates sprite positions along the active trajectories
 func (g *Game) updateSprites() {
-	for i := 0; i < len(g.sprites); i++ {
-		c := g.ctrSprite + float64(i)*0.155
-
-		centerX := float64(screenWidth) / 2
-		centerY := float64(screenHeight) / 2
-
-		posX := centerX + 100*math.Sin(c*1.35+1.25) + 100*math.Sin(c*1.86+0.54)
-		posY := centerY + 60*math.Cos(c*1.72+0.23) + 60*math.Cos(c*1.63+0.98)
-
-		posX += 20 * math.Sin(float64(i)*0.289+1.15)
-		posY += 20 * math.Cos(float64(i)*0.456+0.85)
-
-		halfSize := float64(spriteSize) / 2
-		if posX < halfSize {
-			posX = halfSize
-		} else if posX > screenWidth-halfSize {
-			posX = screenWidth - halfSize
-		}
+	from, to, mix := g.trajectoriesAt(g.iteration)
+	w, h := float64(g.screenW), float64(g.screenH)
+
+	for i, sprite := range g.sprites {
+		fromX, fromY := from.position(i, g.ctrSprite, w, h)
+		fromX, fromY = from.constrain(fromX, fromY, w, h)
+		toX, toY := to.position(i, g.ctrSprite, w, h)
+		toX, toY = to.constrain(toX, toY, w, h)
+
+		sprite.x = fromX + (toX-fromX)*mix
+		sprite.y = fromY + (toY-fromY)*mix
+		sprite.scale = 1
+		sprite.frame = g.spriteFrame(sprite, g.ctrSprite)
+	}
+}
 
-		if posY < halfSize {
-			posY = halfSize
-		} else if posY > screenHeight-halfSize {
-			posY = screenHeight - halfSize
-		}
+// spritesNative reports whether sprites are composed at native resolution,
+// always the case in ST mode so they are reduced to the palette too
+func (g *Game) spritesNative() bool {
+	return g.config.SpritesNative || g.st != nil
+}
 
-		g.sprites[i].x = posX
-		g.sprites[i].y = posY
+// spriteScale returns the scale sprites are drawn at on their layer
+func (g *Game) spriteScale() float64 {
+	if g.spritesNative() {
+		return 1
 	}
+	return float64(g.zoom)
 }
 
-// drawGlowSprite draws a sprite with glow effect
-func (g *Game) drawGlowSprite(screen *ebiten.Image, sprite *Sprite) {
-	if g.config.EnableGlow {
-		// Draw glow layers
-		for i := 3; i > 0; i-- {
-			op := &ebiten.DrawImageOptions{}
-			scale := zoom + float64(i)*0.1
-			op.GeoM.Translate(-float64(spriteSize)/2, -float64(spriteSize)/2)
-			op.GeoM.Scale(scale, scale)
-			op.GeoM.Translate(sprite.x*zoom, sprite.y*zoom)
-			op.ColorM.Scale(1, 1, 1, 0.3/float64(i))
-			op.Filter = ebiten.FilterLinear
-			screen.DrawImage(g.logoImg, op)
-		}
+// drawSprite draws a sprite centred on its position
+func (g *Game) drawSprite(screen *ebiten.Image, sprite *Sprite, scale float64) {
+	img := sprite.frame
+	if img == nil {
+		img = g.logoImg
 	}
+	w := float64(img.Bounds().Dx())
+	h := float64(img.Bounds().Dy())
+
+	// Depth scaling of 3D bobs
+	size := scale * sprite.scale
 
-	// Draw main sprite
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(-float64(spriteSize)/2, -float64(spriteSize)/2)
-	op.GeoM.Scale(zoom, zoom)
-	op.GeoM.Translate(sprite.x*zoom, sprite.y*zoom)
-	screen.DrawImage(g.logoImg, op)
+	op.GeoM.Translate(-w/2, -h/2)
+	op.GeoM.Scale(size, size)
+	op.GeoM.Translate(sprite.x*scale, sprite.y*scale)
+	op.ColorScale.ScaleWithColor(sprite.tint)
+	screen.DrawImage(img, op)
 }
 
-// animIntro handles intro animation
-func (g *Game) animIntro() {
+// animIntro handles intro animation, returning true once the text is done
+func (g *Game) animIntro() bool {
 	if g.introX < 0 {
 		if g.introTile > -1 {
 			char := g.getLetter(g.introText, g.introTile)
@@ -709,11 +999,7 @@ func (g *Game) animIntro() {
 		}
 		g.introLetter++
 		if g.introLetter >= len([]rune(g.introText)) {
-			g.lastState = g.state
-			g.state = "splash"
-			g.iteration = 0
-			g.transitionProgress = 0
-			return
+			return true
 		}
 		g.introTile = g.introLetter
 	}
@@ -721,7 +1007,7 @@ func (g *Game) animIntro() {
 
 	// Scroll temp canvas
 	g.surfScroll2.Clear()
-	srcRect := image.Rect(g.introSpeed, 0, screenWidth+48, fontHeight)
+	srcRect := image.Rect(g.introSpeed, 0, g.screenW+48, fontHeight)
 	op := &ebiten.DrawImageOptions{}
 	g.surfScroll2.DrawImage(g.surfScroll1.SubImage(srcRect).(*ebiten.Image), op)
 
@@ -733,30 +1019,24 @@ func (g *Game) animIntro() {
 	if letter, ok := g.letterData[char]; ok {
 		srcRect := image.Rect(letter.x, letter.y, letter.x+letter.width, letter.y+36)
 		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(float64(screenWidth+g.introX), 0)
+		op.GeoM.Translate(float64(g.screenW+g.introX), 0)
 		g.surfScroll1.DrawImage(g.fontImg.SubImage(srcRect).(*ebiten.Image), op)
 	}
 
 	// Draw to main surface
 	g.surfMain.Fill(color.Black)
-	op = &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(0, 170)
-	g.surfMain.DrawImage(g.surfScroll1, op)
+	g.drawRasterBackdrop(g.surfMain)
+	g.drawRasterLines(g.surfMain, g.surfScroll1, 0, float64(g.screenH*introY/overscanHeight))
+	return false
 }
 
-// animSplash handles splash screen
-func (g *Game) animSplash() {
-	if g.iteration < 90 {
-		g.iteration++
-		g.transitionProgress = float64(g.iteration) / 90.0
-	} else {
-		g.lastState = g.state
-		g.state = "demo"
-		g.iteration = 0
-		g.transitionProgress = 0
-	}
-
+// animSplash handles splash screen, returning true once it has been shown
+func (g *Game) animSplash() bool {
 	g.surfMain.Fill(color.Black)
+	g.drawRasterBackdrop(g.surfMain)
+	g.drawSplashLogo()
+	g.iteration++
+	return g.iteration >= g.config.Splash.Duration
 }
 
 // animDemo handles main demo animation
@@ -768,18 +1048,23 @@ func (g *Game) animDemo() {
 	g.iteration++
 	g.backWavePos = g.iteration * 5
 	g.frontWavePos = g.iteration * 10
-	g.ctrSprite += 0.02
+	g.ctrSprite++
 }
 
 // calculateAndRenderDemo calculates and renders a demo frame
 func (g *Game) calculateAndRenderDemo() {
 	// Bounce values - smoother calculation
-	bounceBack := int(math.Floor(30.0 * math.Abs(math.Sin(float64(g.iteration)*0.1))))
-	bounceFront := int(math.Floor(18.0 * math.Abs(math.Sin(float64(g.iteration)*0.1))))
+	bounce := math.Abs(math.Sin(float64(g.iteration) * 0.1))
+	// Follow the beat whenever music is playing or being captured
+	if g.config.BeatBounce && (g.audioPlayer != nil || g.offline != nil) {
+		bounce = g.beatLevel
+	}
+	bounceBack := int(math.Floor(30.0 * bounce))
+	bounceFront := int(math.Floor(18.0 * bounce))
 
 	// Calculate decal_x
 	decalX := 999999999
-	for ligne := 0; ligne < screenHeight; ligne++ {
+	for ligne := 0; ligne < g.screenH; ligne++ {
 		c := g.getWave(g.frontWavePos+ligne, g.frontIntroWave, g.frontMainWave)
 		if c < decalX {
 			decalX = c
@@ -801,15 +1086,14 @@ func (g *Game) calculateAndRenderDemo() {
 
 	for decalX < g.getPosition(g.letterNum+i) || g.getPosition(g.letterNum+i+1) <= decalX {
 		i += dir
-		if g.letterNum+i < 0 || g.letterNum+i >= len(g.position) {
+		if g.letterNum+i < 0 {
 			break
 		}
 	}
+	// Past the last letter the text wraps seamlessly, see getPosition
 	g.letterNum += i
 	if g.letterNum < 0 {
 		g.letterNum = 0
-	} else if g.letterNum >= len(g.position) {
-		g.letterNum = len(g.position) - 1
 	}
 	g.letterDecal = g.getPosition(g.letterNum)
 
@@ -820,32 +1104,38 @@ func (g *Game) calculateAndRenderDemo() {
 	g.surfMain.Clear()
 
 	// Draw line by line for proper layering
-	for ligne := 0; ligne < screenHeight; ligne++ {
+	for ligne := 0; ligne < g.screenH; ligne++ {
 		// Background
 		backWave := g.getWave(g.backWavePos+ligne, g.backIntroWave, g.backMainWave)
 		backX := (80 + backWave/2) % g.backImg.Bounds().Dx()
 
 		// Ensure we have enough width for the distortion
-		srcWidth := screenWidth
+		srcWidth := g.screenW
 		if backX+srcWidth > g.surfBack.Bounds().Dx() {
 			// Wrap around if needed
 			backX = backX % g.surfBack.Bounds().Dx()
 		}
 
-		// Draw background line using DrawImage
+		// Draw background line using DrawImage, over the raster behind it
+		behind := g.drawRasterBehind(g.surfMain, ligne)
 		srcRect := image.Rect(backX, (ligne+bounceBack)%backHeight, backX+srcWidth, ((ligne+bounceBack)%backHeight)+1)
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Translate(0, float64(ligne))
+		rasterTint(g.rasterBack, ligne, op)
+		if behind {
+			op.Blend = rasterScreenBlend
+		}
 		g.surfMain.DrawImage(g.surfBack.SubImage(srcRect).(*ebiten.Image), op)
 
 		// Text scroll
 		frontWave := g.getWave(g.frontWavePos+ligne, g.frontIntroWave, g.frontMainWave)
 		scrollX := frontWave - g.letterDecal
 
-		if scrollX >= 0 && scrollX < g.surfScroll.Bounds().Dx()-screenWidth {
-			srcRect := image.Rect(scrollX, (ligne+bounceFront)%fontHeight, scrollX+screenWidth, ((ligne+bounceFront)%fontHeight)+1)
+		if scrollX >= 0 && scrollX < g.surfScroll.Bounds().Dx()-g.screenW {
+			srcRect := image.Rect(scrollX, (ligne+bounceFront)%fontHeight, scrollX+g.screenW, ((ligne+bounceFront)%fontHeight)+1)
 			op := &ebiten.DrawImageOptions{}
 			op.GeoM.Translate(0, float64(ligne))
+			rasterTint(g.rasterFont, ligne, op)
 			g.surfMain.DrawImage(g.surfScroll.SubImage(srcRect).(*ebiten.Image), op)
 		}
 	}
@@ -853,71 +1143,61 @@ func (g *Game) calculateAndRenderDemo() {
 
 // Delete renderDemoFrame as it's no longer needed
 
-// drawTransition draws transition effects between states
-func (g *Game) drawTransition(screen *ebiten.Image, progress float64) {
-	if progress > 0 && progress < 1 {
-		overlay := ebiten.NewImage(screenWidth*zoom, screenHeight*zoom)
-
-		// Fade effect
-		alpha := uint8(255 * (1 - progress))
-		overlay.Fill(color.RGBA{0, 0, 0, alpha})
-
-		// Optional: Add more complex transition effects
-		if g.lastState == "splash" && g.state == "demo" {
-			// Zoom in effect
-			op := &ebiten.DrawImageOptions{}
-			scale := 1.0 + (1.0-progress)*0.2
-			op.GeoM.Translate(-float64(screenWidth*zoom)/2, -float64(screenHeight*zoom)/2)
-			op.GeoM.Scale(scale, scale)
-			op.GeoM.Translate(float64(screenWidth*zoom)/2, float64(screenHeight*zoom)/2)
-			screen.DrawImage(overlay, op)
-		} else {
-			screen.DrawImage(overlay, nil)
-		}
-	}
-}
-
 // Update updates the game state
 func (g *Game) Update() error {
 	// Handle fullscreen toggle
-	if ebiten.IsKeyPressed(ebiten.KeyF11) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
 		ebiten.SetFullscreen(!ebiten.IsFullscreen())
 	}
 
-	// Handle volume control
-	if g.audioPlayer != nil {
-		if ebiten.IsKeyPressed(ebiten.KeyUp) {
-			vol := g.audioPlayer.Volume() + 0.01
-			if vol > 1.0 {
-				vol = 1.0
-			}
-			g.audioPlayer.SetVolume(vol)
+	// Screenshot of the next frame
+	if inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		g.screenshotPending = true
+	}
+
+	// Handle audio controls and part navigation, captures run unattended
+	if g.offline == nil {
+		g.handleAudioKeys()
+		g.handlePartKeys()
+	}
+
+	// Freeze the whole timeline while paused
+	if g.paused {
+		return nil
+	}
+
+	// Exit once the faded out frame has been drawn, before pulling the audio
+	// of a tick that would never get a video frame
+	if g.fadeOut >= fadeOutTicks {
+		if err := g.stopCapture(); err != nil {
+			return err
 		}
-		if ebiten.IsKeyPressed(ebiten.KeyDown) {
-			vol := g.audioPlayer.Volume() - 0.01
-			if vol < 0 {
-				vol = 0
-			}
-			g.audioPlayer.SetVolume(vol)
+		return ebiten.Termination
+	}
+
+	// Pull exactly one tick of audio when capturing
+	if g.offline != nil {
+		if err := g.captureFrame(); err != nil {
+			return err
 		}
 	}
 
-	// Update based on state
-	switch g.state {
-	case "intro":
-		g.animIntro()
-	case "splash":
-		g.animSplash()
-	case "demo":
-		g.animDemo()
+	// Advance beat level
+	if g.beat != nil {
+		g.beatLevel = g.beat.tick()
+	}
+	if g.analyser != nil {
+		g.analyser.update()
 	}
 
-	// Update transition
-	if g.transitionProgress > 0 && g.transitionProgress < 1 {
-		g.transitionProgress += 0.02
-		if g.transitionProgress > 1 {
-			g.transitionProgress = 1
-		}
+	// Run the current part
+	g.seq.update(g)
+	g.ticks++
+
+	// Fade everything out before exiting
+	if g.fadeOut > 0 {
+		g.fadeOut++
+		g.applyVolume()
 	}
 
 	return nil
@@ -925,62 +1205,120 @@ func (g *Game) Update() error {
 
 // Draw draws the game
 func (g *Game) Draw(screen *ebiten.Image) {
-	// Apply CRT shader only in intro state
-	if g.config.EnableCRT && g.crtShader != nil && g.state == "intro" {
-		// Create a temporary image at the target size for the shader
-		tmpImg := ebiten.NewImage(screenWidth*zoom, screenHeight*zoom)
+	// Compose the native resolution frame, with sprites if they share it
+	g.surfComp.Clear()
+	g.surfComp.DrawImage(g.surfMain, nil)
+	if g.spritesNative() {
+		g.seq.draw(g, g.surfComp)
+	}
 
-		// Draw main surface scaled to the temporary image
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Scale(zoom, zoom)
-		tmpImg.DrawImage(g.surfMain, op)
-
-		// Apply CRT shader
-		shaderOp := &ebiten.DrawRectShaderOptions{}
-		shaderOp.Images[0] = tmpImg
-		screen.DrawRectShader(screenWidth*zoom, screenHeight*zoom, g.crtShader, shaderOp)
-	} else {
-		// Draw main surface with zoom (no shader)
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Scale(zoom, zoom)
-		screen.DrawImage(g.surfMain, op)
+	// Reduce the native frame to the ST palette
+	native := g.surfComp
+	if g.st != nil {
+		native = g.st.apply(g.surfComp, g.config.STMode.LinePalette)
 	}
 
-	// Draw sprites (always without shader)
-	if g.state == "demo" {
-		g.updateSprites()
-		for _, sprite := range g.sprites {
-			g.drawGlowSprite(screen, sprite)
-		}
+	// Draw main surface with zoom
+	g.surfScaled.Clear()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(g.zoom), float64(g.zoom))
+	g.surfScaled.DrawImage(native, op)
+
+	// Draw part overlays such as sprites at output resolution
+	if !g.spritesNative() {
+		g.seq.draw(g, g.surfScaled)
+	}
+
+	// Run the post-processing chain into the output frame, kept offscreen
+	// so transitions can move it around
+	g.runPostChain(g.surfOut, g.surfScaled)
+
+	// Draw spectrum analyser
+	if g.analyser != nil {
+		g.analyser.draw(g.surfOut, &g.config.Analyser, float64(g.zoom))
 	}
 
 	// Draw transition
-	g.drawTransition(screen, g.transitionProgress)
+	g.surfView.Clear()
+	g.drawTransition(g.surfView, g.surfOut, g.seq.trans, g.transitionProgress)
+
+	// Draw exit fade
+	if g.fadeOut > 0 {
+		alpha := uint8(255 * math.Min(1, float64(g.fadeOut)/fadeOutTicks))
+		b := g.surfView.Bounds()
+		vector.DrawFilledRect(g.surfView, 0, 0, float32(b.Dx()), float32(b.Dy()), color.RGBA{0, 0, 0, alpha}, false)
+	}
+
+	// Record the finished frame, errors end the capture on the next tick
+	if err := g.recordFrame(g.surfView); err != nil {
+		g.recordErr = err
+	}
+
+	// Save a screenshot when asked
+	if g.screenshotPending {
+		g.screenshotPending = false
+		g.takeScreenshot(native, g.surfView)
+	}
+
+	// Scale the frame into the window
+	g.drawViewport(screen, g.surfView)
+
+	// Draw volume indicator
+	g.drawVolume(screen)
 
 	// Draw debug info (optional)
 	if ebiten.IsKeyPressed(ebiten.KeyTab) {
-		ebitenutil.DebugPrint(screen, fmt.Sprintf("FPS: %0.2f\nTPS: %0.2f\nSprites: %d\nState: %s\nCRT: %v",
+		stages := make([]string, len(g.post))
+		for i, stage := range g.post {
+			stages[i] = stage.name()
+		}
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("FPS: %0.2f\nTPS: %0.2f\nSprites: %d\nPart: %s\nPost: %s",
 			ebiten.CurrentFPS(),
 			ebiten.CurrentTPS(),
 			len(g.sprites),
-			g.state,
-			g.config.EnableCRT && g.state == "intro"))
+			g.seq.current().Name(),
+			strings.Join(stages, ", ")))
 	}
 }
 
-// Layout returns the screen dimensions
+// Layout uses the whole window in device pixels and fits the frame in it with
+// the viewport
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth * zoom, screenHeight * zoom
+	scale := ebiten.Monitor().DeviceScaleFactor()
+	w := int(math.Ceil(float64(outsideWidth) * scale))
+	h := int(math.Ceil(float64(outsideHeight) * scale))
+	g.updateViewport(w, h)
+	return w, h
 }
 
 func main() {
-	// Set window properties
-	ebiten.SetWindowSize(screenWidth*zoom, screenHeight*zoom)
-	ebiten.SetWindowTitle("DMA IS BACK IN 2025 - GOLANG/EBITEN POWER :)")
-	ebiten.SetWindowResizable(true)
+	// Parse command line
+	var capture captureOptions
+	flag.StringVar(&capture.wavPath, "wav", "", "render the soundtrack to a 44.1 kHz 16-bit WAV file")
+	flag.StringVar(&capture.aviPath, "record", "", "render the demo with its soundtrack to an AVI file")
+	flag.StringVar(&capture.codec, "codec", "mjpeg", "video codec of -record: raw or mjpeg")
+	flag.IntVar(&capture.scale, "scale", windowZoom, "size of the recorded video as a multiple of the internal resolution")
+	flag.IntVar(&capture.frames, "frames", 0, "number of frames (at 60 per second) to capture")
+	gifRange := flag.String("gif", "", "record the ticks start,end to an animated GIF")
+	flag.StringVar(&capture.gifPath, "gifout", "clip.gif", "output file of -gif")
+	flag.Parse()
+
+	if *gifRange != "" {
+		var err error
+		capture.gifStart, capture.gifEnd, err = parseTickRange(*gifRange)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// Create and initialize game
 	game := NewGame()
+
+	// Set window properties
+	ebiten.SetWindowSize(game.windowSize())
+	ebiten.SetWindowTitle("DMA IS BACK IN 2025 - GOLANG/EBITEN POWER :)")
+	ebiten.SetWindowResizable(true)
+	game.capture = capture
 	if err := game.Init(); err != nil {
 		log.Fatal(err)
 	}
@@ -1019,10 +1357,95 @@ require (
     "fullscreen": false,
     "vsync": true,
     "musicVolume": 0.7,
+    "playlist": ["assets/music.mp3"],
+    "screenshotDir": "screenshots",
     "spriteCount": 10,
     "distortionRate": 1.0,
     "enableCRT": true,
-    "enableGlow": true
+    "resolution": "overscan",
+    "scaling": "integer",
+    "crt": {
+        "barrel": 0.15,
+        "scanlines": 0.04,
+        "rgbShift": 0.002,
+        "vignette": 0.5
+    },
+    "bloom": {
+        "intensity": 0.8,
+        "threshold": 0.6,
+        "radius": 6
+    },
+    "post": [
+        {"shader": "bloom"},
+        {"shader": "chromatic", "uniforms": {"Amount": 0.003}},
+        {"shader": "phosphor", "uniforms": {"Decay": [0.55, 0.65, 0.45]}},
+        {"shader": "crt"},
+        {"shader": "grain", "uniforms": {"Amount": 0.04}}
+    ],
+    "rasters": [
+        {"parts": ["demo"], "target": "behind", "kind": "bars", "count": 3,
+         "colors": ["#FF4040", "#40FF40", "#4040FF"], "height": 16, "start": 120, "fade": 60},
+        {"parts": ["intro"], "target": "font", "kind": "gradient",
+         "colors": ["#FFFF00", "#FF4000"], "height": 36, "speed": 0.5}
+    ],
+    "stMode": {
+        "enabled": false,
+        "depth": "st",
+        "linePalette": false
+    },
+    "spritesNative": true,
+    "spriteSheet": {
+        "generate": "spin",
+        "frames": 16,
+        "fps": 30,
+        "mode": "loop",
+        "frameOffset": 1,
+        "tints": ["#FFFFFF", "#FFD0D0", "#D0FFD0", "#D0D0FF"]
+    },
+    "spriteMode": "path",
+    "bobs": {
+        "shape": "sphere",
+        "speed": [0.011, 0.017, 0.005]
+    },
+    "trails": {
+        "mode": "history",
+        "decay": 0.08,
+        "length": 8
+    },
+    "spritePath": "classic",
+    "spriteTimeline": [
+        {"tick": 1200, "path": "figure8", "blend": 120},
+        {"tick": 2400, "path": "snake", "blend": 120},
+        {"tick": 3600, "path": "classic", "blend": 120}
+    ],
+    "parts": ["intro", "splash", "demo"],
+    "scrollEnd": "loop",
+    "transitions": [
+        {"from": "intro", "to": "splash", "effect": "fade", "duration": 30},
+        {"from": "splash", "to": "demo", "effect": "raster", "duration": 60, "easing": "out"}
+    ],
+    "splash": {
+        "logo": "assets/logo.png",
+        "scale": 4,
+        "effect": "raster",
+        "groupName": "DMA",
+        "duration": 180
+    },
+    "beatSensitivity": 1.4,
+    "beatBounce": true,
+    "beatGlow": true,
+    "beatVignette": false,
+    "analyser": {
+        "enabled": true,
+        "x": 8,
+        "y": 8,
+        "width": 96,
+        "height": 36,
+        "bands": 16,
+        "colorLow": "#00FF00",
+        "colorHigh": "#FF0000",
+        "colorPeak": "#FFFFFF"
+    }
 }
 */
 
@@ -1058,8 +1481,25 @@ A Go/Ebiten port of the classic Atari ST demo "ParaDis3" by Dyno.
 - Sinusoidal text scrolling with multiple wave patterns
 - Background parallax scrolling
 - Animated logo sprites with complex trajectories
+- Internal resolution presets: ST low 320x200, overscan 416x276 and full
+  overscan 448x288
+- Resizable window with integer (letterboxed), fit, stretch and ST pixel
+  aspect scaling modes
 - Optional CRT shader effect
-- Glow effects on sprites
+- Bloom post-process (intensity, threshold, radius)
+- Post-processing chain: built-in crt, bloom, chromatic, grain and phosphor
+  (afterglow with per-channel decay) stages
+  plus custom .kage shaders loaded from disk (Time and Resolution uniforms
+  are provided)
+- Raster colours per scanline: gradients and sine-moving copper bars that
+  tint the background or font or show behind the picture, per part, with
+  start/end/fade on the part timeline
+- Atari ST colour emulation: 9-bit ST or 12-bit STE palette, optionally
+  limited to 16 colours per scanline, with the sprites composed natively
+- Sprites composed at native resolution so they share the pixel grid and
+  post effects
+- Sprite sheet animation (loop or ping-pong) with per-sprite frame offset
+  and tint, or a generated spinning logo
 - Configurable settings via config.json
 
 ## Requirements
@@ -1081,10 +1521,43 @@ go build -o megadist
 ./megadist
 ```
 
+To render the soundtrack of the first 60 seconds to a WAV file:
+
+```bash
+./megadist -wav out.wav -frames 3600
+```
+
+To record the demo with its soundtrack to an AVI file (MJPEG by default, or
+uncompressed RGB with `-codec raw`) at twice the internal resolution:
+
+```bash
+./megadist -record demo.avi -frames 3600 -scale 2
+```
+
+To post a short clip, record ticks 600 to 900 at the internal resolution to an
+animated GIF (30 frames per second, one median cut palette):
+
+```bash
+./megadist -gif 600,900 -gifout clip.gif -scale 1
+```
+
+Recording runs as fast as the machine allows rather than in real time. With
+`"scrollEnd": "fadeout"` the `-frames` limit can be left out to record until
+the demo fades out.
+
 ## Controls
 
 - F11: Toggle fullscreen
+- F12: Save a screenshot, the native frame and the final screen as PNGs
+  tagged with the part, iteration and wave positions
 - Up/Down arrows: Adjust volume
+- M: Mute
+- P: Pause/resume music and demo
+- Left/Right arrows: Seek back/forward 5 seconds in the track
+- N/B: Next/previous track of the playlist
+- Space/Enter: Skip the current part (e.g. the intro)
+- PageUp/PageDown: Previous/next part
+- Home: Restart from the first part
 - Tab: Show debug information
 
 ## Configuration
@@ -1095,7 +1568,20 @@ Edit `config.json` to customize:
 - Music volume
 - Number of sprites
 - Distortion rate
-- Visual effects (CRT, glow)
+- Visual effects (CRT, bloom)
+- 3D bob mode: sprites on a rotating sphere, torus or cube lattice,
+  depth scaled and sorted
+- Sprite trails: fading stamp buffer or a history of positions
+- Sprite paths as data (presets: classic, circle, figure8, wave, snake)
+  switched on a timeline with blending
+- Part sequence (intro, splash, demo)
+- End of scroll text: loop, restart from the intro, fade out and exit,
+  or advance to the next part
+- Splash logo reveal (raster build-up or distort-in) with group name
+- Transitions per part boundary: fade, white, wipe, blinds, dissolve, raster
+  with linear, in, out, inout or sine easing
+- Beat sync (bounce, bloom and vignette driven by the music)
+- Spectrum analyser overlay (position, size, band count and colours)
 
 ## Assets Required
 
@@ -0,0 +1,46 @@
+// main_test.go
+package main
+
+import "testing"
+
+// Letter widths 10, 15 and 15, so the text is 40 pixels wide
+var testPositions = []int{10, 25, 40}
+
+func TestGetPositionWraps(t *testing.T) {
+	g := &Game{position: testPositions}
+	n := len(testPositions)
+
+	tests := []struct {
+		name string
+		i    int
+		want int
+	}{
+		{"first letter", 0, 0},
+		{"last letter", n - 1, 25},
+		{"end of text", n, 40},
+		{"first letter of second pass", n + 1, 50},
+		{"first letter of third pass", 2*n + 1, 90},
+	}
+	for _, tt := range tests {
+		if got := g.getPosition(tt.i); got != tt.want {
+			t.Errorf("%s: getPosition(%d) = %d, want %d", tt.name, tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestScrollEnded(t *testing.T) {
+	g := &Game{position: testPositions}
+	n := len(testPositions)
+
+	for letterNum := 0; letterNum <= n+1; letterNum++ {
+		g.letterNum = letterNum
+		if got, want := g.scrollEnded(), letterNum >= n; got != want {
+			t.Errorf("scrollEnded() with letterNum %d = %v, want %v", letterNum, got, want)
+		}
+	}
+
+	empty := &Game{}
+	if empty.scrollEnded() {
+		t.Errorf("scrollEnded() without text = true, want false")
+	}
+}
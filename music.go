@@ -0,0 +1,249 @@
+// music.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	volumeStep    = 0.05
+	volumeOSDTime = 120 // frames the volume indicator stays visible
+	keyRepeatWait = 20  // frames before a held key starts repeating
+	keyRepeatRate = 4   // frames between repeats
+	seekStep      = 5 * time.Second
+)
+
+// readAsset reads a file from the embedded assets, falling back to disk
+func readAsset(path string) ([]byte, error) {
+	if data, err := assets.ReadFile(filepath.ToSlash(path)); err == nil {
+		return data, nil
+	}
+	return os.ReadFile(path)
+}
+
+// decodeTrack decodes an mp3 or wav file to a 16-bit stereo stream
+func decodeTrack(path string, sampleRate int) (io.ReadSeeker, int64, error) {
+	data, err := readAsset(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		d, err := mp3.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, err
+		}
+		return d, d.Length(), nil
+	case ".wav":
+		d, err := wav.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, err
+		}
+		return d, d.Length(), nil
+	}
+	return nil, 0, fmt.Errorf("unsupported audio format: %s", path)
+}
+
+// openTrack decodes a playlist entry as an endless, tapped stream
+func (g *Game) openTrack(index int) (io.ReadSeeker, error) {
+	path := g.config.Playlist[index]
+	d, length, err := decodeTrack(path, g.audioContext.SampleRate())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	g.trackLength = time.Duration(length) * time.Second / time.Duration(g.audioContext.SampleRate()*4)
+
+	sinks := []sampleSink{g.beat}
+	if g.analyser != nil {
+		sinks = append(sinks, g.analyser)
+	}
+	return newMusicTap(audio.NewInfiniteLoop(d, length), sinks...), nil
+}
+
+// playTrack starts the given playlist entry, replacing the current player
+func (g *Game) playTrack(index int) error {
+	n := len(g.config.Playlist)
+	if n == 0 {
+		return fmt.Errorf("empty playlist")
+	}
+	index = (index%n + n) % n
+
+	if g.audioPlayer != nil {
+		g.audioPlayer.Close()
+		g.audioPlayer = nil
+	}
+
+	stream, err := g.openTrack(index)
+	if err != nil {
+		return err
+	}
+
+	g.audioPlayer, err = g.audioContext.NewPlayer(stream)
+	if err != nil {
+		return err
+	}
+
+	g.track = index
+	g.applyVolume()
+	if !g.paused {
+		g.audioPlayer.Play()
+	}
+	return nil
+}
+
+// applyVolume pushes the volume, mute and exit fade state to the player
+func (g *Game) applyVolume() {
+	if g.audioPlayer == nil {
+		return
+	}
+	if g.muted {
+		g.audioPlayer.SetVolume(0)
+	} else {
+		fade := 1 - math.Min(1, float64(g.fadeOut)/fadeOutTicks)
+		g.audioPlayer.SetVolume(g.volume * fade)
+	}
+}
+
+// keyRepeated reports a key press with auto-repeat while held
+func keyRepeated(key ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || (d > keyRepeatWait && (d-keyRepeatWait)%keyRepeatRate == 0)
+}
+
+// handleAudioKeys processes mute, pause, volume, seek and track keys
+func (g *Game) handleAudioKeys() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		g.paused = !g.paused
+		if g.audioPlayer != nil {
+			if g.paused {
+				g.audioPlayer.Pause()
+			} else {
+				g.audioPlayer.Play()
+			}
+		}
+		g.volumeOSD = volumeOSDTime
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.muted = !g.muted
+		g.applyVolume()
+		g.volumeOSD = volumeOSDTime
+	}
+
+	if keyRepeated(ebiten.KeyUp) {
+		g.volume = math.Min(1, g.volume+volumeStep)
+		g.muted = false
+		g.applyVolume()
+		g.volumeOSD = volumeOSDTime
+	}
+	if keyRepeated(ebiten.KeyDown) {
+		g.volume = math.Max(0, g.volume-volumeStep)
+		g.applyVolume()
+		g.volumeOSD = volumeOSDTime
+	}
+
+	seek := time.Duration(0)
+	if keyRepeated(ebiten.KeyRight) {
+		seek = seekStep
+	}
+	if keyRepeated(ebiten.KeyLeft) {
+		seek = -seekStep
+	}
+	if seek != 0 && g.audioPlayer != nil && g.trackLength > 0 {
+		// Wrap around the looping track, the stream rejects negative positions
+		pos := ((g.trackPosition()+seek)%g.trackLength + g.trackLength) % g.trackLength
+		if err := g.audioPlayer.SetPosition(pos); err != nil {
+			g.audioError = err.Error()
+		} else {
+			g.audioError = ""
+		}
+		g.volumeOSD = volumeOSDTime
+	}
+
+	delta := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		delta = 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+		delta = -1
+	}
+	if delta != 0 && len(g.config.Playlist) > 0 {
+		if err := g.playTrack(g.track + delta); err != nil {
+			g.audioError = err.Error()
+		} else {
+			g.audioError = ""
+		}
+		g.volumeOSD = volumeOSDTime
+	}
+
+	if g.volumeOSD > 0 {
+		g.volumeOSD--
+	}
+}
+
+// trackPosition returns the play position within the looping track
+func (g *Game) trackPosition() time.Duration {
+	if g.audioPlayer == nil || g.trackLength <= 0 {
+		return 0
+	}
+	return g.audioPlayer.Position() % g.trackLength
+}
+
+// drawVolume draws the on-screen volume indicator
+func (g *Game) drawVolume(screen *ebiten.Image) {
+	if g.volumeOSD <= 0 && !g.paused {
+		return
+	}
+
+	h := float32(screen.Bounds().Dy())
+	x, y := float32(12), h-36
+
+	vector.DrawFilledRect(screen, x, y, 128, 8, color.RGBA{32, 32, 32, 200}, false)
+	level := g.volume
+	if g.muted {
+		level = 0
+	}
+	vector.DrawFilledRect(screen, x, y, float32(128*level), 8, color.RGBA{255, 224, 64, 255}, false)
+
+	label := fmt.Sprintf("VOL %3d%%", int(math.Round(g.volume*100)))
+	if g.muted {
+		label = "MUTE"
+	}
+	if len(g.config.Playlist) > 1 {
+		label += fmt.Sprintf("  TRACK %d/%d", g.track+1, len(g.config.Playlist))
+	}
+	if g.trackLength > 0 {
+		label += "  " + formatDuration(g.trackPosition()) + "/" + formatDuration(g.trackLength)
+	}
+	if g.paused {
+		label += "  PAUSED"
+	}
+	if g.audioError != "" {
+		label = g.audioError
+	}
+	ebitenutil.DebugPrintAt(screen, label, int(x), int(y)+10)
+}
+
+// formatDuration formats a duration as minutes and seconds
+func formatDuration(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
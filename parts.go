@@ -0,0 +1,229 @@
+// parts.go
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Part is one screen of the demo, run by the sequencer
+type Part interface {
+	Name() string
+	Enter(g *Game)
+	Update(g *Game)
+	Draw(g *Game, screen *ebiten.Image)
+	Exit(g *Game)
+	Done() bool
+}
+
+// partFactories maps the part names usable in the config to constructors
+var partFactories = map[string]func() Part{
+	"intro":  func() Part { return &introPart{} },
+	"splash": func() Part { return &splashPart{} },
+	"demo":   func() Part { return &demoPart{} },
+}
+
+// introPart is the typewriter scroll shown before the demo
+type introPart struct {
+	done bool
+}
+
+func (p *introPart) Name() string { return "intro" }
+
+func (p *introPart) Enter(g *Game) {
+	p.done = false
+	g.iteration = 0
+	g.introX = -1
+	g.introLetter = -1
+	g.introTile = -1
+	g.surfScroll1.Clear()
+	g.surfScroll2.Clear()
+}
+
+func (p *introPart) Update(g *Game) {
+	p.done = g.animIntro()
+}
+
+func (p *introPart) Draw(g *Game, screen *ebiten.Image) {}
+
+func (p *introPart) Exit(g *Game) {}
+
+func (p *introPart) Done() bool { return p.done }
+
+// splashPart is a short pause between the intro and the demo
+type splashPart struct {
+	done bool
+}
+
+func (p *splashPart) Name() string { return "splash" }
+
+func (p *splashPart) Enter(g *Game) {
+	p.done = false
+	g.iteration = 0
+}
+
+func (p *splashPart) Update(g *Game) {
+	p.done = g.animSplash()
+}
+
+func (p *splashPart) Draw(g *Game, screen *ebiten.Image) {}
+
+func (p *splashPart) Exit(g *Game) {}
+
+func (p *splashPart) Done() bool { return p.done }
+
+// demoPart is the parallax distorter itself
+type demoPart struct {
+	done bool
+}
+
+func (p *demoPart) Name() string { return "demo" }
+
+func (p *demoPart) Enter(g *Game) {
+	p.done = false
+	g.iteration = 0
+	g.backWavePos = 0
+	g.frontWavePos = 0
+	g.letterNum = 0
+	g.letterDecal = 0
+	g.ctrSprite = 0
+	g.clearTrails()
+}
+
+func (p *demoPart) Update(g *Game) {
+	g.animDemo()
+	g.updateSpriteLayer()
+
+	if !g.scrollEnded() {
+		return
+	}
+	switch g.config.ScrollEnd {
+	case "restart":
+		g.seq.request(0)
+	case "fadeout":
+		if g.fadeOut == 0 {
+			g.fadeOut = 1
+		}
+	case "next":
+		p.done = true
+	}
+}
+
+func (p *demoPart) Draw(g *Game, screen *ebiten.Image) {
+	g.drawSprites(screen)
+}
+
+func (p *demoPart) Exit(g *Game) {}
+
+func (p *demoPart) Done() bool { return p.done }
+
+// sequencer runs a list of parts, starting a transition at each boundary
+type sequencer struct {
+	parts   []Part
+	index   int
+	started bool
+	prev    string
+	trans   *transition
+	elapsed int
+	ticks   int // ticks since the part started, for the raster timeline
+	pending int // part requested by request, -1 if none
+}
+
+// newSequencer builds the part list from names, skipping unknown ones
+func newSequencer(names []string) *sequencer {
+	s := &sequencer{pending: -1}
+	for _, name := range names {
+		factory, ok := partFactories[name]
+		if !ok {
+			log.Printf("Warning: Unknown part %q", name)
+			continue
+		}
+		s.parts = append(s.parts, factory())
+	}
+	if len(s.parts) == 0 {
+		s.parts = append(s.parts, &demoPart{})
+	}
+	return s
+}
+
+// current returns the running part
+func (s *sequencer) current() Part {
+	return s.parts[s.index]
+}
+
+// jump exits the running part and enters the part at index, wrapping around
+func (s *sequencer) jump(g *Game, index int) {
+	n := len(s.parts)
+	index = (index%n + n) % n
+
+	if s.started {
+		s.current().Exit(g)
+		s.prev = s.current().Name()
+	}
+	s.started = true
+	s.index = index
+	s.current().Enter(g)
+
+	// Reveal the new part with the transition configured for this boundary
+	s.trans = g.pickTransition(s.prev, s.current().Name())
+	s.elapsed = 0
+	s.ticks = 0
+	g.transitionProgress = 0
+}
+
+// request asks for a jump to the part at index on the next tick
+func (s *sequencer) request(index int) {
+	s.pending = index
+}
+
+// update runs one tick of the current part and advances when it is done
+func (s *sequencer) update(g *Game) {
+	if !s.started {
+		s.jump(g, 0)
+	}
+	if s.pending >= 0 {
+		s.jump(g, s.pending)
+		s.pending = -1
+	}
+
+	g.updateRasters(s.current().Name(), s.ticks)
+	s.ticks++
+
+	s.current().Update(g)
+	if s.current().Done() {
+		s.jump(g, s.index+1)
+		return
+	}
+
+	if s.elapsed < s.trans.duration {
+		s.elapsed++
+		g.transitionProgress = float64(s.elapsed) / float64(s.trans.duration)
+	}
+}
+
+// draw lets the current part draw on top of the main surface
+func (s *sequencer) draw(g *Game, screen *ebiten.Image) {
+	if s.started {
+		s.current().Draw(g, screen)
+	}
+}
+
+// handlePartKeys routes the skip and navigation keys through the sequencer
+func (g *Game) handlePartKeys() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace),
+		inpututil.IsKeyJustPressed(ebiten.KeyEnter),
+		inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		g.seq.request(g.seq.index + 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		g.seq.request(g.seq.index - 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		g.seq.request(0)
+		if g.fadeOut > 0 {
+			g.fadeOut = 0
+			g.applyVolume()
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// postfx.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// PostStageConfig is one stage of the post-processing chain. Shader is a
+// built-in stage name or the path of a .kage file, Uniforms override the
+// stage defaults
+type PostStageConfig struct {
+	Shader   string         `json:"shader"`
+	Uniforms map[string]any `json:"uniforms"`
+}
+
+// Chromatic aberration shader source
+const chromaticShaderSrc = `//kage:unit pixels
+
+package main
+
+var Amount float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var origin vec2
+	var size vec2
+	origin = imageSrc0Origin()
+	size = imageSrc0Size()
+
+	var off vec2
+	off = ((srcPos-origin)/size - 0.5) * Amount * size
+
+	var c vec4
+	c = imageSrc0At(srcPos)
+	c.r = imageSrc0At(srcPos + off).r
+	c.b = imageSrc0At(srcPos - off).b
+	return c * color
+}
+`
+
+// Film grain shader source
+const grainShaderSrc = `//kage:unit pixels
+
+package main
+
+var Amount float
+var Time float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var c vec4
+	c = imageSrc0At(srcPos)
+	var n float
+	n = fract(sin(dot(dstPos.xy+Time*vec2(17.0, 31.0), vec2(12.9898, 78.233))) * 43758.5453)
+	c.rgb = c.rgb + (n-0.5)*Amount*c.a
+	return c * color
+}
+`
+
+// Phosphor persistence shader source, image 1 is the previous frame
+const phosphorShaderSrc = `//kage:unit pixels
+
+package main
+
+var Decay vec3
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var cur vec4
+	var prev vec4
+	cur = imageSrc0At(srcPos)
+	prev = imageSrc1At(srcPos - imageSrc0Origin() + imageSrc1Origin())
+	return vec4(max(cur.rgb, prev.rgb*Decay), max(cur.a, prev.a)) * color
+}
+`
+
+// postStage is one step of the post-processing chain
+type postStage interface {
+	name() string
+	apply(g *Game, dst, src *ebiten.Image)
+}
+
+// shaderStage runs a Kage shader over the frame. Defaults provides the
+// per-frame uniforms, overridden by the uniforms from the config
+type shaderStage struct {
+	label     string
+	shader    *ebiten.Shader
+	defaults  func(g *Game, w, h int) map[string]any
+	overrides map[string]any
+}
+
+func (s *shaderStage) name() string { return s.label }
+
+func (s *shaderStage) apply(g *Game, dst, src *ebiten.Image) {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	uniforms := map[string]any{}
+	if s.defaults != nil {
+		uniforms = s.defaults(g, w, h)
+	}
+	for k, v := range s.overrides {
+		uniforms[k] = v
+	}
+
+	op := &ebiten.DrawRectShaderOptions{}
+	op.Images[0] = src
+	op.Uniforms = uniforms
+	dst.DrawRectShader(w, h, s.shader, op)
+}
+
+// bloomStage copies the frame and blooms it in place
+type bloomStage struct {
+	bloom *bloom
+	cfg   BloomConfig
+}
+
+func (s *bloomStage) name() string { return "bloom" }
+
+func (s *bloomStage) apply(g *Game, dst, src *ebiten.Image) {
+	dst.DrawImage(src, nil)
+
+	intensity := s.cfg.Intensity
+	if g.config.BeatGlow {
+		intensity *= 1 + g.beatLevel
+	}
+	s.bloom.apply(dst, &s.cfg, intensity, g.zoom)
+}
+
+// phosphorStage blends the previous output of the stage into the frame, the
+// history buffer is owned by the game so it survives chain rebuilds. The
+// history only advances once per simulated tick, so the decay doesn't depend
+// on the display refresh rate and stops while paused
+type phosphorStage struct {
+	shader *ebiten.Shader
+	decay  []float32
+}
+
+func (s *phosphorStage) name() string { return "phosphor" }
+
+func (s *phosphorStage) apply(g *Game, dst, src *ebiten.Image) {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if g.surfHistory == nil || g.surfHistory.Bounds().Dx() != w || g.surfHistory.Bounds().Dy() != h {
+		g.surfHistory = ebiten.NewImage(w, h)
+	}
+
+	op := &ebiten.DrawRectShaderOptions{}
+	op.Images[0] = src
+	op.Images[1] = g.surfHistory
+	op.Uniforms = map[string]any{
+		"Decay": s.decay,
+	}
+	dst.DrawRectShader(w, h, s.shader, op)
+
+	if g.historyTick != g.ticks {
+		g.historyTick = g.ticks
+		g.surfHistory.Clear()
+		g.surfHistory.DrawImage(dst, nil)
+	}
+}
+
+// crtUniforms returns the CRT uniforms, with one scanline per native line
+func crtUniforms(g *Game, w, h int) map[string]any {
+	cfg := &g.config.CRT
+
+	// Keep the scanline period a whole number of output pixels to avoid moiré
+	pixelsPerLine := math.Max(1, math.Round(float64(h)/float64(g.screenH)))
+	lines := float64(h) / pixelsPerLine
+
+	vignette := cfg.Vignette
+	if g.config.BeatVignette {
+		vignette *= 1 + g.beatLevel
+	}
+
+	return map[string]any{
+		"Barrel":       cfg.Barrel,
+		"Scanlines":    cfg.Scanlines,
+		"ScanlineFreq": 2 * math.Pi * lines,
+		"RGBShift":     cfg.RGBShift,
+		"Vignette":     vignette,
+	}
+}
+
+// builtinShaders maps the built-in shader stage names to source and defaults
+var builtinShaders = map[string]struct {
+	src      string
+	defaults func(g *Game, w, h int) map[string]any
+}{
+	"crt": {crtShaderSrc, crtUniforms},
+	"chromatic": {chromaticShaderSrc, func(g *Game, w, h int) map[string]any {
+		return map[string]any{"Amount": 0.004}
+	}},
+	"grain": {grainShaderSrc, func(g *Game, w, h int) map[string]any {
+		return map[string]any{"Amount": 0.06, "Time": float64(g.iteration)}
+	}},
+}
+
+// defaultPostChain derives the chain from the legacy enableCRT and bloom settings
+func (g *Game) defaultPostChain() []PostStageConfig {
+	var chain []PostStageConfig
+	if g.config.Bloom.Intensity > 0 {
+		chain = append(chain, PostStageConfig{Shader: "bloom"})
+	}
+	if g.config.EnableCRT {
+		chain = append(chain, PostStageConfig{Shader: "crt"})
+	}
+	return chain
+}
+
+// buildPostChain compiles the configured stages, skipping broken ones
+func (g *Game) buildPostChain() {
+	chain := g.config.Post
+	if chain == nil {
+		chain = g.defaultPostChain()
+	}
+
+	g.post = nil
+	for i, cfg := range chain {
+		stage, err := g.newPostStage(cfg)
+		if err == nil {
+			err = g.tryPostStage(stage)
+		}
+		if err != nil {
+			log.Printf("Warning: Post stage %d (%s) disabled: %v", i+1, cfg.Shader, err)
+			continue
+		}
+		g.post = append(g.post, stage)
+	}
+}
+
+// tryPostStage runs a stage once on a blank frame, as ebiten panics on
+// uniforms that don't match the types declared by the shader
+func (g *Game) tryPostStage(stage postStage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("uniform mismatch: %v", r)
+		}
+	}()
+
+	src := ebiten.NewImage(g.screenW, g.screenH)
+	dst := ebiten.NewImage(g.screenW, g.screenH)
+	defer src.Deallocate()
+	defer dst.Deallocate()
+	stage.apply(g, dst, src)
+	return nil
+}
+
+// newPostStage creates a built-in stage or compiles a .kage file from disk
+func (g *Game) newPostStage(cfg PostStageConfig) (postStage, error) {
+	overrides, err := jsonUniforms(cfg.Uniforms)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Shader == "bloom" {
+		b, err := newBloom()
+		if err != nil {
+			return nil, err
+		}
+		bcfg := g.config.Bloom
+		if v, ok := overrides["Intensity"].(float64); ok {
+			bcfg.Intensity = v
+		}
+		if v, ok := overrides["Threshold"].(float64); ok {
+			bcfg.Threshold = v
+		}
+		if v, ok := overrides["Radius"].(float64); ok {
+			bcfg.Radius = v
+		}
+		return &bloomStage{bloom: b, cfg: bcfg}, nil
+	}
+
+	if cfg.Shader == "phosphor" {
+		shader, err := ebiten.NewShader([]byte(phosphorShaderSrc))
+		if err != nil {
+			return nil, err
+		}
+		decay := []float32{0.55, 0.65, 0.45}
+		if v, ok := overrides["Decay"].([]float32); ok && len(v) == 3 {
+			decay = v
+		}
+		return &phosphorStage{shader: shader, decay: decay}, nil
+	}
+
+	if builtin, ok := builtinShaders[cfg.Shader]; ok {
+		shader, err := ebiten.NewShader([]byte(builtin.src))
+		if err != nil {
+			return nil, err
+		}
+		return &shaderStage{label: cfg.Shader, shader: shader, defaults: builtin.defaults, overrides: overrides}, nil
+	}
+
+	src, err := os.ReadFile(cfg.Shader)
+	if err != nil {
+		return nil, fmt.Errorf("unknown built-in stage and %v", err)
+	}
+	shader, err := ebiten.NewShader(src)
+	if err != nil {
+		return nil, fmt.Errorf("compile error in %s:\n%v", cfg.Shader, err)
+	}
+	return &shaderStage{
+		label:  cfg.Shader,
+		shader: shader,
+		defaults: func(g *Game, w, h int) map[string]any {
+			return map[string]any{
+				"Time":       float64(g.iteration),
+				"Resolution": []float32{float32(w), float32(h)},
+			}
+		},
+		overrides: overrides,
+	}, nil
+}
+
+// jsonUniforms converts JSON arrays to the float slices Kage expects, only
+// numbers and arrays of numbers are valid uniform values
+func jsonUniforms(in map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		switch v := v.(type) {
+		case float64:
+			out[k] = v
+		case []any:
+			vals := make([]float32, 0, len(v))
+			for _, e := range v {
+				f, ok := e.(float64)
+				if !ok {
+					return nil, fmt.Errorf("uniform %s: array elements must be numbers", k)
+				}
+				vals = append(vals, float32(f))
+			}
+			out[k] = vals
+		default:
+			return nil, fmt.Errorf("uniform %s: value must be a number or an array of numbers", k)
+		}
+	}
+	return out, nil
+}
+
+// runPostChain runs the stages from src, ping-ponging between the buffers so
+// that the last stage writes into dst
+func (g *Game) runPostChain(dst, src *ebiten.Image) {
+	n := len(g.post)
+	if n == 0 {
+		dst.Clear()
+		dst.DrawImage(src, nil)
+		return
+	}
+
+	w, h := dst.Bounds().Dx(), dst.Bounds().Dy()
+	if g.surfPing == nil || g.surfPing.Bounds().Dx() != w || g.surfPing.Bounds().Dy() != h {
+		g.surfPing = ebiten.NewImage(w, h)
+	}
+
+	for i, stage := range g.post {
+		out := g.surfPing
+		if (n-1-i)%2 == 0 {
+			out = dst
+		}
+		out.Clear()
+		stage.apply(g, out, src)
+		src = out
+	}
+}
@@ -0,0 +1,252 @@
+// rasters.go
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// RasterConfig is one raster effect, a colour per native scanline computed
+// every tick. "back" and "font" tint those layers, "behind" shows through the
+// dark parts of the picture like a changed colour 0
+type RasterConfig struct {
+	Parts  []string `json:"parts"`  // parts showing the raster, all when empty
+	Target string   `json:"target"` // "back", "font" or "behind"
+	Kind   string   `json:"kind"`   // "gradient" or "bars"
+	Colors []string `json:"colors"` // gradient stops or one colour per bar
+
+	Height int     `json:"height"` // gradient period or bar thickness in lines
+	Count  int     `json:"count"`  // number of bars
+	Y      float64 `json:"y"`      // line the bars swing around
+	Amp    float64 `json:"amp"`    // bar swing in lines
+	Speed  float64 `json:"speed"`  // gradient scroll in lines, bar swing in radians, per tick
+	Spread float64 `json:"spread"` // phase between consecutive bars in radians
+
+	// Timeline, in ticks since the part started
+	Start int `json:"start"`
+	End   int `json:"end"` // 0 keeps the raster until the part ends
+	Fade  int `json:"fade"`
+}
+
+// raster is a configured raster with its colours parsed
+type raster struct {
+	cfg    RasterConfig
+	colors []color.RGBA
+}
+
+// rasterScreenBlend lets the raster behind a line show through its dark pixels
+var rasterScreenBlend = ebiten.Blend{
+	BlendFactorSourceRGB:        ebiten.BlendFactorOne,
+	BlendFactorSourceAlpha:      ebiten.BlendFactorOne,
+	BlendFactorDestinationRGB:   ebiten.BlendFactorOneMinusSourceColor,
+	BlendFactorDestinationAlpha: ebiten.BlendFactorOneMinusSourceAlpha,
+	BlendOperationRGB:           ebiten.BlendOperationAdd,
+	BlendOperationAlpha:         ebiten.BlendOperationAdd,
+}
+
+// loadRasters fills in the raster defaults and parses their colours
+func (g *Game) loadRasters() {
+	g.rasters = nil
+	for i, cfg := range g.config.Rasters {
+		switch cfg.Target {
+		case "back", "font", "behind":
+		default:
+			log.Printf("Warning: Raster %d has unknown target %q", i+1, cfg.Target)
+			continue
+		}
+
+		defColors := []string{"#FF4040", "#40FF40", "#4040FF"}
+		if cfg.Kind == "gradient" {
+			defColors = []string{"#000040", "#4080FF", "#000040"}
+			if cfg.Height <= 0 {
+				cfg.Height = g.screenH
+			}
+		} else {
+			cfg.Kind = "bars"
+			if cfg.Height <= 0 {
+				cfg.Height = 16
+			}
+			if cfg.Count <= 0 {
+				cfg.Count = 1
+			}
+			if cfg.Y == 0 {
+				cfg.Y = float64(g.screenH) / 2
+			}
+			if cfg.Amp == 0 {
+				cfg.Amp = float64(g.screenH) / 3
+			}
+			if cfg.Speed == 0 {
+				cfg.Speed = 0.05
+			}
+			if cfg.Spread == 0 {
+				cfg.Spread = 0.4
+			}
+		}
+		if len(cfg.Colors) == 0 {
+			cfg.Colors = defColors
+		}
+
+		r := &raster{cfg: cfg}
+		for _, s := range cfg.Colors {
+			r.colors = append(r.colors, parseHexColor(s, color.RGBA{255, 255, 255, 255}))
+		}
+		g.rasters = append(g.rasters, r)
+	}
+}
+
+// updateRasters computes the line tables of the rasters active in a part
+func (g *Game) updateRasters(part string, tick int) {
+	g.rasterBack, g.rasterFont, g.rasterBehind = nil, nil, nil
+
+	for _, r := range g.rasters {
+		cfg := &r.cfg
+		if len(cfg.Parts) > 0 && !slices.Contains(cfg.Parts, part) {
+			continue
+		}
+		alpha := r.fade(tick)
+		if alpha <= 0 {
+			continue
+		}
+
+		switch cfg.Target {
+		case "behind":
+			if g.rasterBehind == nil {
+				g.rasterBehind = make([]color.RGBA, g.screenH)
+			}
+			for line := range g.rasterBehind {
+				c := fadeColor(r.colorAt(line, tick), alpha)
+				g.rasterBehind[line] = overColor(c, g.rasterBehind[line])
+			}
+
+		default:
+			table := &g.rasterBack
+			if cfg.Target == "font" {
+				table = &g.rasterFont
+			}
+			if *table == nil {
+				*table = make([]color.RGBA, g.screenH)
+				for line := range *table {
+					(*table)[line] = color.RGBA{255, 255, 255, 255}
+				}
+			}
+			for line := range *table {
+				c := fadeColor(r.colorAt(line, tick), alpha)
+				(*table)[line] = overColor(c, (*table)[line])
+			}
+		}
+	}
+}
+
+// fade returns the opacity of the raster at a tick of its timeline
+func (r *raster) fade(tick int) float64 {
+	cfg := &r.cfg
+	if tick < cfg.Start || (cfg.End > 0 && tick >= cfg.End) {
+		return 0
+	}
+	if cfg.Fade <= 0 {
+		return 1
+	}
+	alpha := math.Min(1, float64(tick-cfg.Start)/float64(cfg.Fade))
+	if cfg.End > 0 {
+		alpha = math.Min(alpha, float64(cfg.End-tick)/float64(cfg.Fade))
+	}
+	return alpha
+}
+
+// colorAt returns the premultiplied colour of the raster on a line
+func (r *raster) colorAt(line, tick int) color.RGBA {
+	cfg := &r.cfg
+
+	if cfg.Kind == "gradient" {
+		if len(r.colors) == 1 {
+			return r.colors[0]
+		}
+		period := float64(cfg.Height)
+		pos := math.Mod(float64(line)+cfg.Speed*float64(tick), period)
+		if pos < 0 {
+			pos += period
+		}
+		pos = pos / period * float64(len(r.colors)-1)
+		i := min(int(pos), len(r.colors)-2)
+		return lerpColor(r.colors[i], r.colors[i+1], pos-float64(i))
+	}
+
+	// Copper bars, bright in the middle and fading out at the edges, later
+	// bars in front
+	var c color.RGBA
+	half := float64(cfg.Height) / 2
+	for k := 0; k < cfg.Count; k++ {
+		centre := cfg.Y + cfg.Amp*math.Sin(cfg.Speed*float64(tick)+cfg.Spread*float64(k))
+		d := math.Abs(float64(line)+0.5-centre) / half
+		if d >= 1 {
+			continue
+		}
+		shade := math.Cos(d * math.Pi / 2)
+		c = overColor(fadeColor(r.colors[k%len(r.colors)], shade), c)
+	}
+	return c
+}
+
+// overColor composites premultiplied colour a over b
+func overColor(a, b color.RGBA) color.RGBA {
+	k := 1 - float64(a.A)/255
+	return color.RGBA{
+		a.R + uint8(float64(b.R)*k),
+		a.G + uint8(float64(b.G)*k),
+		a.B + uint8(float64(b.B)*k),
+		a.A + uint8(float64(b.A)*k),
+	}
+}
+
+// rasterTint scales a line draw by the raster colour of the line, if any
+func rasterTint(table []color.RGBA, line int, op *ebiten.DrawImageOptions) {
+	if line < 0 || line >= len(table) {
+		return
+	}
+	c := table[line]
+	op.ColorScale.Scale(float32(c.R)/255, float32(c.G)/255, float32(c.B)/255, 1)
+}
+
+// drawRasterBehind fills a line with the behind raster, returning true if the
+// line content should then be drawn with rasterScreenBlend
+func (g *Game) drawRasterBehind(dst *ebiten.Image, line int) bool {
+	if line < 0 || line >= len(g.rasterBehind) || g.rasterBehind[line].A == 0 {
+		return false
+	}
+	vector.DrawFilledRect(dst, 0, float32(line), float32(dst.Bounds().Dx()), 1, g.rasterBehind[line], false)
+	return true
+}
+
+// drawRasterLines draws src at x, y line by line so the font raster can tint
+// it, or in one go when there is none
+func (g *Game) drawRasterLines(dst, src *ebiten.Image, x, y float64) {
+	if g.rasterFont == nil {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(x, y)
+		dst.DrawImage(src, op)
+		return
+	}
+
+	b := src.Bounds()
+	for row := b.Min.Y; row < b.Max.Y; row++ {
+		line := int(y) + row - b.Min.Y
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(x, float64(line))
+		rasterTint(g.rasterFont, line, op)
+		dst.DrawImage(src.SubImage(image.Rect(b.Min.X, row, b.Max.X, row+1)).(*ebiten.Image), op)
+	}
+}
+
+// drawRasterBackdrop fills every line with the behind raster, for parts with
+// a plain black background
+func (g *Game) drawRasterBackdrop(dst *ebiten.Image) {
+	for line := range g.rasterBehind {
+		g.drawRasterBehind(dst, line)
+	}
+}
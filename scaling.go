@@ -0,0 +1,102 @@
+// scaling.go
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	overscanHeight = 276 // lines of the overscan screen the layout was made for
+
+	windowZoom    = 2         // initial window scale
+	maxZoom       = 6         // cap on the output frame scale, for fill rate
+	stPixelAspect = 5.0 / 6.0 // width over height of an ST pixel on a 4:3 monitor
+)
+
+// resolutions maps the internal resolution presets to their size
+var resolutions = map[string][2]int{
+	"stlow":        {320, 200},
+	"overscan":     {416, overscanHeight},
+	"fulloverscan": {448, 288},
+}
+
+// viewport is the area of the screen the output frame is drawn into
+type viewport struct {
+	x, y, w, h float64
+}
+
+// computeViewport places a w by h native frame in the outside size according
+// to the scaling mode: "integer" keeps whole multiples and letterboxes, "fit"
+// keeps the aspect ratio, "stretch" fills the window and "st" keeps the
+// aspect ratio of the ST pixels
+func computeViewport(mode string, w, h, outW, outH int) viewport {
+	ow, oh := float64(outW), float64(outH)
+	sx := ow / float64(w)
+	sy := oh / float64(h)
+
+	switch mode {
+	case "stretch":
+		return viewport{0, 0, ow, oh}
+	case "fit":
+		s := math.Min(sx, sy)
+		sx, sy = s, s
+	case "st":
+		s := math.Min(sx, sy*stPixelAspect)
+		sx, sy = s, s/stPixelAspect
+	default:
+		// Capped at the largest frame scale so the final blit stays whole
+		s := math.Max(1, math.Min(maxZoom, math.Floor(math.Min(sx, sy))))
+		sx, sy = s, s
+	}
+
+	vw, vh := float64(w)*sx, float64(h)*sy
+	return viewport{math.Floor((ow - vw) / 2), math.Floor((oh - vh) / 2), vw, vh}
+}
+
+// windowSize returns the initial window size for the scaling mode
+func (g *Game) windowSize() (int, int) {
+	if g.config.Scaling == "st" {
+		return g.screenW * windowZoom, int(math.Round(float64(g.screenH*windowZoom) / stPixelAspect))
+	}
+	return g.screenW * windowZoom, g.screenH * windowZoom
+}
+
+// updateViewport recomputes the viewport for the outside size and resizes the
+// output frame buffers when the scale they are rendered at changes. The frame
+// is rendered at the next whole scale above the viewport and filtered down
+func (g *Game) updateViewport(outW, outH int) {
+	g.viewport = computeViewport(g.config.Scaling, g.screenW, g.screenH, outW, outH)
+
+	scale := math.Max(g.viewport.w/float64(g.screenW), g.viewport.h/float64(g.screenH))
+	zoom := min(maxZoom, max(1, int(math.Ceil(scale-1e-9))))
+	if g.capture.aviPath != "" || g.capture.gifEnd > 0 {
+		// Recorded frames keep their size whatever the window does
+		zoom = max(1, g.capture.scale)
+	}
+	if zoom == g.zoom && g.surfScaled != nil {
+		return
+	}
+
+	g.zoom = zoom
+	g.surfScaled = ebiten.NewImage(g.screenW*zoom, g.screenH*zoom)
+	g.surfOut = ebiten.NewImage(g.screenW*zoom, g.screenH*zoom)
+	g.surfView = ebiten.NewImage(g.screenW*zoom, g.screenH*zoom)
+}
+
+// drawViewport draws the finished frame into the viewport, filtering only
+// when it isn't scaled by a whole number
+func (g *Game) drawViewport(screen, frame *ebiten.Image) {
+	vp := g.viewport
+	fw, fh := float64(frame.Bounds().Dx()), float64(frame.Bounds().Dy())
+
+	op := &ebiten.DrawImageOptions{}
+	kx, ky := vp.w/fw, vp.h/fh
+	op.GeoM.Scale(kx, ky)
+	op.GeoM.Translate(vp.x, vp.y)
+	if kx != math.Trunc(kx) || ky != math.Trunc(ky) {
+		op.Filter = ebiten.FilterLinear
+	}
+	screen.DrawImage(frame, op)
+}
@@ -0,0 +1,103 @@
+// screenshot.go
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// screenshotState is the demo state embedded in screenshots
+type screenshotState struct {
+	key, value string
+}
+
+// takeScreenshot saves the native composite with sprites and the finished
+// output frame as PNGs, tagged with the current demo state
+func (g *Game) takeScreenshot(native, final *ebiten.Image) {
+	// Sprites drawn at output resolution aren't in the native composite, so
+	// draw the part overlay again at native size
+	shot := ebiten.NewImage(g.screenW, g.screenH)
+	shot.DrawImage(native, nil)
+	if !g.spritesNative() {
+		g.seq.draw(g, shot)
+	}
+
+	state := []screenshotState{
+		{"Software", "megadist"},
+		{"Part", g.seq.current().Name()},
+		{"Iteration", fmt.Sprint(g.iteration)},
+		{"BackWavePos", fmt.Sprint(g.backWavePos)},
+		{"FrontWavePos", fmt.Sprint(g.frontWavePos)},
+	}
+
+	base := filepath.Join(g.config.ScreenshotDir, "megadist-"+time.Now().Format("20060102-150405.000"))
+	images := []struct {
+		path string
+		img  *image.RGBA
+	}{
+		{base + "-native.png", readImage(shot)},
+		{base + "-screen.png", readImage(final)},
+	}
+
+	// Encode in the background so the demo doesn't stutter
+	go func() {
+		if err := os.MkdirAll(g.config.ScreenshotDir, 0o755); err != nil {
+			log.Printf("Warning: Could not save screenshot: %v", err)
+			return
+		}
+		for _, file := range images {
+			if err := writePNG(file.path, file.img, state); err != nil {
+				log.Printf("Warning: Could not save screenshot: %v", err)
+				return
+			}
+		}
+		log.Printf("Saved screenshot %s", base)
+	}()
+}
+
+// readImage copies the pixels of an ebiten image
+func readImage(src *ebiten.Image) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	src.ReadPixels(img.Pix)
+	return img
+}
+
+// writePNG encodes img and inserts a tEXt chunk per state entry before IEND
+func writePNG(path string, img image.Image, state []screenshotState) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	// IEND is always the last 12 bytes of the stream
+	data := buf.Bytes()
+	iend := len(data) - 12
+
+	var out bytes.Buffer
+	out.Write(data[:iend])
+	for _, s := range state {
+		out.Write(pngChunk("tEXt", []byte(s.key+"\x00"+s.value)))
+	}
+	out.Write(data[iend:])
+
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// pngChunk encodes a PNG chunk with its length and CRC
+func pngChunk(kind string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], kind)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
@@ -0,0 +1,114 @@
+// splash.go
+package main
+
+import (
+	"bytes"
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	splashFallTicks   = 20 // ticks for a raster line to fall into place
+	splashLetterTicks = 6  // ticks between group name letters
+)
+
+// SplashConfig configures the splash part
+type SplashConfig struct {
+	Logo      string  `json:"logo"`
+	Scale     float64 `json:"scale"`
+	Effect    string  `json:"effect"` // "raster" or "distort"
+	GroupName string  `json:"groupName"`
+	Duration  int     `json:"duration"`
+}
+
+// loadSplash loads the splash logo and precalculates the distort-in wave
+func (g *Game) loadSplash() {
+	g.splashImg = g.logoImg
+	if path := g.config.Splash.Logo; path != "" {
+		if data, err := readAsset(path); err == nil {
+			if img, _, err := ebitenutil.NewImageFromReader(bytes.NewReader(data)); err == nil {
+				g.splashImg = img
+			}
+		}
+	}
+
+	g.precalcWave([]int{bgSin3, bgSin1}, &g.splashWave)
+}
+
+// splashBuildTicks returns how long the logo takes to build up
+func (g *Game) splashBuildTicks() int {
+	return g.config.Splash.Duration / 2
+}
+
+// drawSplashLogo renders the logo reveal onto the main surface
+func (g *Game) drawSplashLogo() {
+	cfg := &g.config.Splash
+	scale := cfg.Scale
+	w := g.splashImg.Bounds().Dx()
+	h := g.splashImg.Bounds().Dy()
+	rows := int(float64(h) * scale)
+
+	left := (float64(g.screenW) - float64(w)*scale) / 2
+	top := (float64(g.screenH)-float64(rows))/2 - fontHeight/2
+	build := float64(g.splashBuildTicks())
+	progress := math.Min(1, float64(g.iteration)/build)
+
+	for row := 0; row < rows; row++ {
+		srcY := int(float64(row) / scale)
+		x := left
+		y := top + float64(row)
+
+		switch cfg.Effect {
+		case "distort":
+			// Lines settle from the background curves as the logo builds up
+			amp := 1 - progress
+			x += float64(g.getSum(g.splashWave, g.iteration*3+row, 0)) * amp
+		default:
+			// Raster build-up, each line falls from the bottom in turn
+			land := float64(row) / float64(rows) * (build - splashFallTicks)
+			t := (float64(g.iteration) - land) / splashFallTicks
+			if t < 0 {
+				continue
+			}
+			if t < 1 {
+				y += (float64(g.screenH) - y) * (1 - t) * (1 - t)
+			}
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, 1)
+		op.GeoM.Translate(x, y)
+		rasterTint(g.rasterBack, int(y), op)
+		g.surfMain.DrawImage(g.splashImg.SubImage(image.Rect(0, srcY, w, srcY+1)).(*ebiten.Image), op)
+	}
+
+	// Group name, typed in once the logo is complete
+	if cfg.GroupName == "" || g.iteration < g.splashBuildTicks() {
+		return
+	}
+
+	runes := []rune(cfg.GroupName)
+	count := min(len(runes), (g.iteration-g.splashBuildTicks())/splashLetterTicks+1)
+
+	width := 0
+	for _, r := range runes {
+		if letter, ok := g.letterData[r]; ok {
+			width += letter.width
+		}
+	}
+
+	x := (g.screenW - width) / 2
+	y := int(top) + rows + 8
+	for _, r := range runes[:count] {
+		letter, ok := g.letterData[r]
+		if !ok {
+			continue
+		}
+		srcRect := image.Rect(letter.x, letter.y, letter.x+letter.width, letter.y+fontHeight)
+		g.drawRasterLines(g.surfMain, g.fontImg.SubImage(srcRect).(*ebiten.Image), float64(x), float64(y))
+		x += letter.width
+	}
+}
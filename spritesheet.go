@@ -0,0 +1,138 @@
+// spritesheet.go
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// SpriteSheetConfig describes the animation used by the logo sprites. Frames
+// come from Rects when given, otherwise from a grid of FrameWidth x
+// FrameHeight cells read row by row. Generate "spin" builds the frames from
+// the logo instead of loading an image
+type SpriteSheetConfig struct {
+	Image       string   `json:"image"`
+	Generate    string   `json:"generate"`
+	FrameWidth  int      `json:"frameWidth"`
+	FrameHeight int      `json:"frameHeight"`
+	Rects       [][4]int `json:"rects"` // x, y, width, height
+	Frames      int      `json:"frames"`
+	FPS         float64  `json:"fps"`
+	Mode        string   `json:"mode"`        // "loop" or "pingpong"
+	FrameOffset int      `json:"frameOffset"` // frames between consecutive sprites
+	Tints       []string `json:"tints"`       // cycled over the sprites
+}
+
+// loadSpriteSheet builds the sprite frames and tints from the config
+func (g *Game) loadSpriteSheet() {
+	cfg := &g.config.SpriteSheet
+	g.spriteFrames = []*ebiten.Image{g.logoImg}
+
+	switch {
+	case cfg.Generate == "spin":
+		g.spriteFrames = spinFrames(g.logoImg, max(2, cfg.Frames))
+
+	case cfg.Image != "":
+		data, err := readAsset(cfg.Image)
+		if err != nil {
+			log.Printf("Warning: Could not load sprite sheet: %v", err)
+			break
+		}
+		sheet, _, err := ebitenutil.NewImageFromReader(bytes.NewReader(data))
+		if err != nil {
+			log.Printf("Warning: Could not decode sprite sheet: %v", err)
+			break
+		}
+		if frames := sliceSheet(sheet, cfg); len(frames) > 0 {
+			g.spriteFrames = frames
+		}
+	}
+
+	tints := make([]color.RGBA, len(cfg.Tints))
+	for i, t := range cfg.Tints {
+		tints[i] = parseHexColor(t, color.RGBA{255, 255, 255, 255})
+	}
+
+	for _, sprite := range g.sprites {
+		sprite.tint = color.RGBA{255, 255, 255, 255}
+		if len(tints) > 0 {
+			sprite.tint = tints[sprite.index%len(tints)]
+		}
+	}
+}
+
+// sliceSheet cuts the frames out of a sprite sheet
+func sliceSheet(sheet *ebiten.Image, cfg *SpriteSheetConfig) []*ebiten.Image {
+	var frames []*ebiten.Image
+
+	for _, r := range cfg.Rects {
+		rect := image.Rect(r[0], r[1], r[0]+r[2], r[1]+r[3])
+		frames = append(frames, sheet.SubImage(rect).(*ebiten.Image))
+	}
+
+	if len(frames) == 0 && cfg.FrameWidth > 0 && cfg.FrameHeight > 0 {
+		b := sheet.Bounds()
+		for y := 0; y+cfg.FrameHeight <= b.Dy(); y += cfg.FrameHeight {
+			for x := 0; x+cfg.FrameWidth <= b.Dx(); x += cfg.FrameWidth {
+				rect := image.Rect(x, y, x+cfg.FrameWidth, y+cfg.FrameHeight)
+				frames = append(frames, sheet.SubImage(rect).(*ebiten.Image))
+			}
+		}
+	}
+
+	if cfg.Frames > 0 && cfg.Frames < len(frames) {
+		frames = frames[:cfg.Frames]
+	}
+	return frames
+}
+
+// spinFrames fakes a rotation around the vertical axis by squashing the logo,
+// the back side is mirrored and darkened
+func spinFrames(src *ebiten.Image, n int) []*ebiten.Image {
+	w := src.Bounds().Dx()
+	h := src.Bounds().Dy()
+	frames := make([]*ebiten.Image, n)
+
+	for i := range frames {
+		scale := math.Cos(2 * math.Pi * float64(i) / float64(n))
+		frames[i] = ebiten.NewImage(w, h)
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(-float64(w)/2, 0)
+		op.GeoM.Scale(scale, 1)
+		op.GeoM.Translate(float64(w)/2, 0)
+		if scale < 0 {
+			op.ColorScale.Scale(0.6, 0.6, 0.6, 1)
+		}
+		op.Filter = ebiten.FilterLinear
+		frames[i].DrawImage(src, op)
+	}
+	return frames
+}
+
+// spriteFrame returns the animation frame for a sprite at the given tick
+func (g *Game) spriteFrame(sprite *Sprite, tick float64) *ebiten.Image {
+	n := len(g.spriteFrames)
+	if n == 1 {
+		return g.spriteFrames[0]
+	}
+
+	cfg := &g.config.SpriteSheet
+	f := int(tick*cfg.FPS/60) + sprite.index*cfg.FrameOffset
+
+	if cfg.Mode == "pingpong" {
+		period := 2*n - 2
+		f = (f%period + period) % period
+		if f >= n {
+			f = period - f
+		}
+		return g.spriteFrames[f]
+	}
+	return g.spriteFrames[(f%n+n)%n]
+}
@@ -0,0 +1,168 @@
+// stpalette.go
+package main
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const stLineColors = 16 // colours per scanline without raster tricks
+
+// STModeConfig configures the Atari ST colour emulation of the native frame
+type STModeConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Depth       string `json:"depth"`       // "st" (9-bit) or "ste" (12-bit)
+	LinePalette bool   `json:"linePalette"` // at most 16 colours per scanline
+}
+
+// Colour quantisation shader source
+const quantizeShaderSrc = `//kage:unit pixels
+
+package main
+
+var Levels float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var c vec4
+	c = imageSrc0At(srcPos)
+	if c.a == 0.0 {
+		return c
+	}
+	var rgb vec3
+	rgb = floor(c.rgb/c.a*Levels + 0.5) / Levels
+	return vec4(rgb*c.a, c.a)
+}
+`
+
+// stMode holds the resources of the ST colour emulation
+type stMode struct {
+	levels int
+	shader *ebiten.Shader
+	out    *ebiten.Image
+	pixels []byte
+	counts []int
+	remap  []int
+}
+
+// newSTMode compiles the quantisation shader for the configured depth
+func newSTMode(cfg *STModeConfig) (*stMode, error) {
+	m := &stMode{levels: 7}
+	if cfg.Depth == "ste" {
+		m.levels = 15
+	}
+
+	var err error
+	m.shader, err = ebiten.NewShader([]byte(quantizeShaderSrc))
+	if err != nil {
+		return nil, err
+	}
+
+	n := (m.levels + 1) * (m.levels + 1) * (m.levels + 1)
+	m.counts = make([]int, n)
+	m.remap = make([]int, n)
+	return m, nil
+}
+
+// apply quantises src and returns the image to use as the native frame
+func (m *stMode) apply(src *ebiten.Image, linePalette bool) *ebiten.Image {
+	if linePalette {
+		m.applyLinePalette(src)
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if m.out == nil || m.out.Bounds().Dx() != w || m.out.Bounds().Dy() != h {
+		m.out = ebiten.NewImage(w, h)
+	}
+
+	op := &ebiten.DrawRectShaderOptions{}
+	op.Images[0] = src
+	op.Uniforms = map[string]any{
+		"Levels": float32(m.levels),
+	}
+	m.out.DrawRectShader(w, h, m.shader, op)
+	return m.out
+}
+
+// applyLinePalette quantises on the CPU and keeps the 16 most used colours of
+// each scanline, mapping the others to the nearest of those. Like the shader
+// it works on unpremultiplied colours and leaves transparent pixels alone
+func (m *stMode) applyLinePalette(img *ebiten.Image) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if len(m.pixels) != w*h*4 {
+		m.pixels = make([]byte, w*h*4)
+	}
+	img.ReadPixels(m.pixels)
+
+	l := m.levels
+	side := l + 1
+	level := func(v, a byte) int { return (int(v)*l + int(a)/2) / int(a) }
+	value := func(q int, a byte) byte { return byte((q*255/l*int(a) + 127) / 255) }
+	keyOf := func(p []byte) int {
+		return (level(p[0], p[3])*side+level(p[1], p[3]))*side + level(p[2], p[3])
+	}
+
+	var used []int
+	for y := 0; y < h; y++ {
+		row := m.pixels[y*w*4 : (y+1)*w*4]
+
+		// Quantise and count the colours of the line
+		used = used[:0]
+		for x := 0; x < w; x++ {
+			p := row[x*4 : x*4+4]
+			if p[3] == 0 {
+				continue
+			}
+			key := keyOf(p)
+			if m.counts[key] == 0 {
+				used = append(used, key)
+			}
+			m.counts[key]++
+		}
+
+		// Pick the palette of the line
+		for _, key := range used {
+			m.remap[key] = key
+		}
+		if len(used) > stLineColors {
+			sort.Slice(used, func(i, j int) bool { return m.counts[used[i]] > m.counts[used[j]] })
+			palette := used[:stLineColors]
+			for _, key := range used[stLineColors:] {
+				m.remap[key] = nearestKey(key, palette, side)
+			}
+		}
+
+		// Write the remapped colours back
+		for x := 0; x < w; x++ {
+			p := row[x*4 : x*4+4]
+			if p[3] == 0 {
+				continue
+			}
+			key := m.remap[keyOf(p)]
+			p[0] = value(key/(side*side), p[3])
+			p[1] = value(key/side%side, p[3])
+			p[2] = value(key%side, p[3])
+		}
+
+		for _, key := range used {
+			m.counts[key] = 0
+		}
+	}
+
+	img.WritePixels(m.pixels)
+}
+
+// nearestKey returns the palette entry closest to key in level space
+func nearestKey(key int, palette []int, side int) int {
+	r, g, b := key/(side*side), key/side%side, key%side
+	best, bestDist := palette[0], -1
+	for _, p := range palette {
+		dr, dg, db := p/(side*side)-r, p/side%side-g, p%side-b
+		dist := dr*dr + dg*dg + db*db
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = p, dist
+		}
+	}
+	return best
+}
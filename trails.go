@@ -0,0 +1,129 @@
+// trails.go
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TrailsConfig configures the sprite trails. "decay" stamps the sprites into
+// a buffer that is never cleared but fades by Decay each frame, "history"
+// keeps the last Length positions and draws them with decreasing alpha
+type TrailsConfig struct {
+	Mode   string  `json:"mode"` // "", "decay" or "history"
+	Decay  float64 `json:"decay"`
+	Length int     `json:"length"`
+}
+
+// updateSpriteLayer moves the sprites and records their trails for this tick
+func (g *Game) updateSpriteLayer() {
+	sprites := g.sprites
+	if g.config.SpriteMode == "bobs" {
+		g.updateBobs()
+		sprites = g.drawOrder
+	} else {
+		g.updateSprites()
+	}
+
+	switch g.config.Trails.Mode {
+	case "decay":
+		g.stampTrail(sprites)
+	case "history":
+		g.recordTrail(sprites)
+	}
+}
+
+// recordTrail stores the sprites in the history ring, overwriting the oldest
+// snapshot once Length are kept
+func (g *Game) recordTrail(sprites []*Sprite) {
+	if len(g.trailHistory) != g.config.Trails.Length {
+		g.trailHistory = make([][]Sprite, g.config.Trails.Length)
+		g.trailNext, g.trailCount = 0, 0
+	}
+
+	snapshot := g.trailHistory[g.trailNext][:0]
+	for _, sprite := range sprites {
+		snapshot = append(snapshot, *sprite)
+	}
+	g.trailHistory[g.trailNext] = snapshot
+	g.trailNext = (g.trailNext + 1) % len(g.trailHistory)
+	g.trailCount = min(g.trailCount+1, len(g.trailHistory))
+}
+
+// stampTrail fades the trail buffer and stamps the sprites into it
+func (g *Game) stampTrail(sprites []*Sprite) {
+	scale := g.spriteScale()
+	w, h := int(float64(g.screenW)*scale), int(float64(g.screenH)*scale)
+	if g.trailBack == nil || g.trailBack.Bounds().Dx() != w {
+		g.trailFront = ebiten.NewImage(w, h)
+		g.trailBack = ebiten.NewImage(w, h)
+	}
+
+	// Images can't be drawn onto themselves, so fade into the other buffer
+	keep := float32(1 - g.config.Trails.Decay)
+	g.trailBack.Clear()
+	op := &ebiten.DrawImageOptions{}
+	op.ColorScale.Scale(keep, keep, keep, keep)
+	g.trailBack.DrawImage(g.trailFront, op)
+
+	for _, sprite := range sprites {
+		g.drawSprite(g.trailBack, sprite, scale)
+	}
+	g.trailFront, g.trailBack = g.trailBack, g.trailFront
+}
+
+// clearTrails forgets all trails, e.g. when the demo part restarts
+func (g *Game) clearTrails() {
+	g.trailNext, g.trailCount = 0, 0
+	if g.trailFront != nil {
+		g.trailFront.Clear()
+	}
+}
+
+// drawSprites draws the sprites and their trails onto a layer, at the scale
+// of the layer relative to the native frame
+func (g *Game) drawSprites(dst *ebiten.Image) {
+	scale := float64(dst.Bounds().Dx()) / float64(g.screenW)
+
+	switch g.config.Trails.Mode {
+	case "decay":
+		if g.trailFront != nil {
+			op := &ebiten.DrawImageOptions{}
+			k := scale / g.spriteScale()
+			op.GeoM.Scale(k, k)
+			dst.DrawImage(g.trailFront, op)
+		}
+		return
+
+	case "history":
+		// Oldest first, the newest snapshot is the live sprites drawn below
+		n := g.trailCount
+		for i := 0; i < n-1; i++ {
+			snapshot := g.trailHistory[(g.trailNext-n+i+len(g.trailHistory))%len(g.trailHistory)]
+			alpha := float64(i+1) / float64(n)
+			for _, sprite := range snapshot {
+				sprite.tint = fadeColor(sprite.tint, alpha)
+				g.drawSprite(dst, &sprite, scale)
+			}
+		}
+	}
+
+	sprites := g.sprites
+	if g.config.SpriteMode == "bobs" {
+		sprites = g.drawOrder
+	}
+	for _, sprite := range sprites {
+		g.drawSprite(dst, sprite, scale)
+	}
+}
+
+// fadeColor scales a premultiplied colour by alpha
+func fadeColor(c color.RGBA, alpha float64) color.RGBA {
+	return color.RGBA{
+		uint8(float64(c.R) * alpha),
+		uint8(float64(c.G) * alpha),
+		uint8(float64(c.B) * alpha),
+		uint8(float64(c.A) * alpha),
+	}
+}
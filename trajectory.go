@@ -0,0 +1,191 @@
+// trajectory.go
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+)
+
+// Wave is one term of a trajectory: Amp * sin(Freq*c + Phase), cosine if Cos
+type Wave struct {
+	Amp   float64 `json:"amp"`
+	Freq  float64 `json:"freq"`
+	Phase float64 `json:"phase"`
+	Cos   bool    `json:"cos"`
+}
+
+// Trajectory describes a sprite path as a sum of sines per axis around the
+// screen centre. X and Y are driven by the sprite counter, IndexX and IndexY
+// by the sprite index to spread the sprites out, DriftX and DriftY add a
+// linear motion per counter unit
+type Trajectory struct {
+	X      []Wave  `json:"x"`
+	Y      []Wave  `json:"y"`
+	IndexX []Wave  `json:"indexX"`
+	IndexY []Wave  `json:"indexY"`
+	DriftX float64 `json:"driftX"`
+	DriftY float64 `json:"driftY"`
+	Spread float64 `json:"spread"` // counter offset between sprites
+	Speed  float64 `json:"speed"`  // counter increment per tick
+	Edge   string  `json:"edge"`   // "clamp" or "wrap"
+}
+
+// TrajectoryCue switches to a trajectory at a demo tick, blending over Blend ticks
+type TrajectoryCue struct {
+	Tick  int    `json:"tick"`
+	Path  string `json:"path"`
+	Blend int    `json:"blend"`
+}
+
+// trajectoryPresets are the built-in sprite paths
+var trajectoryPresets = map[string]*Trajectory{
+	"classic": {
+		X:      []Wave{{Amp: 100, Freq: 1.35, Phase: 1.25}, {Amp: 100, Freq: 1.86, Phase: 0.54}},
+		Y:      []Wave{{Amp: 60, Freq: 1.72, Phase: 0.23, Cos: true}, {Amp: 60, Freq: 1.63, Phase: 0.98, Cos: true}},
+		IndexX: []Wave{{Amp: 20, Freq: 0.289, Phase: 1.15}},
+		IndexY: []Wave{{Amp: 20, Freq: 0.456, Phase: 0.85, Cos: true}},
+		Spread: 0.155,
+		Speed:  0.02,
+		Edge:   "clamp",
+	},
+	"circle": {
+		X:      []Wave{{Amp: 110, Freq: 1}},
+		Y:      []Wave{{Amp: 100, Freq: 1, Cos: true}},
+		Spread: 2 * math.Pi / 10,
+		Speed:  0.02,
+		Edge:   "clamp",
+	},
+	"figure8": {
+		X:      []Wave{{Amp: 160, Freq: 1}},
+		Y:      []Wave{{Amp: 90, Freq: 2}},
+		Spread: 0.25,
+		Speed:  0.015,
+		Edge:   "clamp",
+	},
+	"wave": {
+		Y:      []Wave{{Amp: 70, Freq: 3}},
+		DriftX: -400,
+		Spread: 0.08,
+		Speed:  0.01,
+		Edge:   "wrap",
+	},
+	"snake": {
+		X:      []Wave{{Amp: 150, Freq: 1.1}, {Amp: 30, Freq: 3.7, Phase: 0.4}},
+		Y:      []Wave{{Amp: 80, Freq: 1.9, Phase: 0.7, Cos: true}, {Amp: 20, Freq: 4.3}},
+		Spread: 0.09,
+		Speed:  0.025,
+		Edge:   "clamp",
+	},
+}
+
+// sumWaves evaluates a list of waves at c
+func sumWaves(waves []Wave, c float64) float64 {
+	v := 0.0
+	for _, w := range waves {
+		if w.Cos {
+			v += w.Amp * math.Cos(w.Freq*c+w.Phase)
+		} else {
+			v += w.Amp * math.Sin(w.Freq*c+w.Phase)
+		}
+	}
+	return v
+}
+
+// position returns the unconstrained position of sprite i at the given tick,
+// around the centre of a w by h screen
+func (t *Trajectory) position(i int, tick, w, h float64) (float64, float64) {
+	c := tick*t.Speed + float64(i)*t.Spread
+	x := w/2 + sumWaves(t.X, c) + sumWaves(t.IndexX, float64(i)) + t.DriftX*c
+	y := h/2 + sumWaves(t.Y, c) + sumWaves(t.IndexY, float64(i)) + t.DriftY*c
+	return x, y
+}
+
+// constrain applies the edge behaviour so the sprite stays on a w by h screen
+func (t *Trajectory) constrain(x, y, w, h float64) (float64, float64) {
+	halfSize := float64(spriteSize) / 2
+
+	if t.Edge == "wrap" {
+		wrap := func(v, size float64) float64 {
+			span := size + spriteSize
+			return math.Mod(math.Mod(v+halfSize, span)+span, span) - halfSize
+		}
+		return wrap(x, w), wrap(y, h)
+	}
+
+	return math.Max(halfSize, math.Min(w-halfSize, x)),
+		math.Max(halfSize, math.Min(h-halfSize, y))
+}
+
+// trajectory looks a path up in the config first, then in the presets
+func (g *Game) trajectory(name string) *Trajectory {
+	if t, ok := g.config.Trajectories[name]; ok {
+		return t
+	}
+	if t, ok := trajectoryPresets[name]; ok {
+		return t
+	}
+	return nil
+}
+
+// validateTrajectories fills in the defaults of config paths and drops
+// timeline cues that name unknown paths
+func (g *Game) validateTrajectories() {
+	for name, t := range g.config.Trajectories {
+		if t == nil {
+			log.Printf("Warning: Sprite path %q is empty", name)
+			delete(g.config.Trajectories, name)
+			continue
+		}
+		if t.Speed == 0 {
+			t.Speed = 0.02
+		}
+		// Without a spread or index terms every sprite would sit on the same spot
+		if t.Spread == 0 && len(t.IndexX) == 0 && len(t.IndexY) == 0 {
+			t.Spread = 0.155
+		}
+		switch t.Edge {
+		case "clamp", "wrap":
+		case "":
+			t.Edge = "clamp"
+		default:
+			log.Printf("Warning: Sprite path %q has unknown edge mode %q", name, t.Edge)
+			t.Edge = "clamp"
+		}
+	}
+
+	if g.trajectory(g.config.SpritePath) == nil {
+		log.Printf("Warning: Unknown sprite path %q", g.config.SpritePath)
+		g.config.SpritePath = "classic"
+	}
+
+	cues := g.config.SpriteTimeline[:0]
+	for _, cue := range g.config.SpriteTimeline {
+		if g.trajectory(cue.Path) == nil {
+			log.Printf("Warning: Unknown sprite path %q in timeline", cue.Path)
+			continue
+		}
+		cues = append(cues, cue)
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Tick < cues[j].Tick })
+	g.config.SpriteTimeline = cues
+}
+
+// trajectoriesAt returns the paths active at a tick and the blend between them
+func (g *Game) trajectoriesAt(tick int) (*Trajectory, *Trajectory, float64) {
+	from := g.trajectory(g.config.SpritePath)
+	to := from
+	mix := 1.0
+
+	for _, cue := range g.config.SpriteTimeline {
+		if cue.Tick > tick {
+			break
+		}
+		from, to = to, g.trajectory(cue.Path)
+		mix = 1.0
+		if cue.Blend > 0 {
+			mix = math.Min(1, float64(tick-cue.Tick)/float64(cue.Blend))
+		}
+	}
+	return from, to, mix
+}
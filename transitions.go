@@ -0,0 +1,172 @@
+// transitions.go
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	defaultTransitionTicks = 50
+	blindsCount            = 12
+	paletteSteps           = 7 // ST palette levels above black per channel
+)
+
+// TransitionConfig selects the effect used when entering a part, an empty
+// or "*" From/To matches any part
+type TransitionConfig struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Effect   string `json:"effect"`
+	Duration int    `json:"duration"`
+	Easing   string `json:"easing"`
+}
+
+// Dissolve shader source
+const dissolveShaderSrc = `//kage:unit pixels
+
+package main
+
+var Progress float
+var Block float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	var cell vec2
+	cell = floor(dstPos.xy / Block)
+	var n float
+	n = fract(sin(dot(cell, vec2(12.9898, 78.233))) * 43758.5453)
+	if n < Progress {
+		return imageSrc0At(srcPos)
+	}
+	return vec4(0.0, 0.0, 0.0, 1.0)
+}
+`
+
+// transition is a configured effect with its easing curve
+type transition struct {
+	effect   string
+	duration int
+	ease     func(float64) float64
+}
+
+// easings maps the easing names usable in the config
+var easings = map[string]func(float64) float64{
+	"linear": func(t float64) float64 { return t },
+	"in":     func(t float64) float64 { return t * t },
+	"out":    func(t float64) float64 { return t * (2 - t) },
+	"inout": func(t float64) float64 {
+		if t < 0.5 {
+			return 2 * t * t
+		}
+		return -1 + (4-2*t)*t
+	},
+	"sine": func(t float64) float64 { return 0.5 - 0.5*math.Cos(t*math.Pi) },
+}
+
+// transitionEffects lists the known effect names
+var transitionEffects = map[string]bool{
+	"fade":     true,
+	"white":    true,
+	"wipe":     true,
+	"blinds":   true,
+	"dissolve": true,
+	"raster":   true,
+}
+
+// pickTransition returns the first configured transition matching the boundary
+func (g *Game) pickTransition(from, to string) *transition {
+	t := &transition{effect: "fade", duration: defaultTransitionTicks, ease: easings["linear"]}
+
+	for _, c := range g.config.Transitions {
+		if (c.From != "" && c.From != "*" && c.From != from) || (c.To != "" && c.To != "*" && c.To != to) {
+			continue
+		}
+		if transitionEffects[c.Effect] {
+			t.effect = c.Effect
+		} else {
+			log.Printf("Warning: Unknown transition effect %q", c.Effect)
+		}
+		if c.Duration > 0 {
+			t.duration = c.Duration
+		}
+		if ease, ok := easings[c.Easing]; ok {
+			t.ease = ease
+		}
+		break
+	}
+
+	if t.effect == "dissolve" && g.dissolveShader == nil {
+		t.effect = "fade"
+	}
+	return t
+}
+
+// drawTransition draws the frame to the screen, partially revealed by the
+// transition effect at the given progress
+func (g *Game) drawTransition(screen, frame *ebiten.Image, t *transition, progress float64) {
+	if t == nil || progress >= 1 {
+		screen.DrawImage(frame, nil)
+		return
+	}
+
+	p := t.ease(math.Max(0, progress))
+	w := frame.Bounds().Dx()
+	h := frame.Bounds().Dy()
+
+	switch t.effect {
+	case "fade":
+		screen.DrawImage(frame, nil)
+		vector.DrawFilledRect(screen, 0, 0, float32(w), float32(h), color.RGBA{0, 0, 0, uint8(255 * (1 - p))}, false)
+
+	case "white":
+		// Step like an ST palette fade, one colour level at a time
+		level := math.Floor((1-p)*paletteSteps) / paletteSteps
+		screen.DrawImage(frame, nil)
+		a := uint8(255 * level)
+		vector.DrawFilledRect(screen, 0, 0, float32(w), float32(h), color.RGBA{a, a, a, a}, false)
+
+	case "wipe":
+		screen.Fill(color.Black)
+		edge := int(math.Round(float64(w) * p))
+		screen.DrawImage(frame.SubImage(image.Rect(0, 0, edge, h)).(*ebiten.Image), nil)
+
+	case "blinds":
+		screen.Fill(color.Black)
+		strip := (h + blindsCount - 1) / blindsCount
+		open := int(math.Round(float64(strip) * p))
+		for y := 0; y < h; y += strip {
+			rect := image.Rect(0, y, w, min(y+open, h))
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(0, float64(y))
+			screen.DrawImage(frame.SubImage(rect).(*ebiten.Image), op)
+		}
+
+	case "dissolve":
+		op := &ebiten.DrawRectShaderOptions{}
+		op.Images[0] = frame
+		op.Uniforms = map[string]any{
+			"Progress": p,
+			"Block":    float32(g.zoom),
+		}
+		screen.DrawRectShader(w, h, g.dissolveShader, op)
+
+	case "raster":
+		// Alternate scanlines slide in from opposite sides
+		screen.Fill(color.Black)
+		offset := float64(w) * (1 - p)
+		for y := 0; y < h; y += g.zoom {
+			dir := 1.0
+			if (y/g.zoom)%2 == 1 {
+				dir = -1.0
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(dir*offset, float64(y))
+			screen.DrawImage(frame.SubImage(image.Rect(0, y, w, y+g.zoom)).(*ebiten.Image), op)
+		}
+	}
+}